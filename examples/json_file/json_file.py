@@ -1,3 +1,4 @@
+import glob
 import os
 import sys
 import json
@@ -49,6 +50,21 @@ if __name__ == '__main__':
         else:
             result = {}
 
+    elif event == "import":
+        # The import id is the filename, return the data in it as the config
+        fr = open(input_dict["id"], mode='r')
+        data = fr.read()
+        fr.close()
+        result = json.loads(data) if len(data) > 0 else {}
+
+    elif event == "list":
+        # Every file made by this script is an object which can be imported
+        result = []
+        for name in glob.glob(os.path.join(tempfile.gettempdir(), os.path.basename(script) + "*")):
+            with open(name, mode='r') as fr:
+                data = fr.read()
+            result.append({"id": name, "config": json.loads(data) if len(data) > 0 else {}})
+
     elif event == "update":
         # First get the creation date if we can - need to save it again
         fr = open(id, mode='r+')
@@ -61,9 +61,9 @@ resource "linux_json_file" "i" {
 }
 
 output "hp_name" {
-  value = jsondecode(universe_json_file.hp.config)["name"]
+  value = universe_json_file.hp.outputs["name"]
 }
 
 output "hp_created" {
-  value = jsondecode(universe_json_file.hp.config)["@created"]
+  value = universe_json_file.hp.outputs["@created"]
 }
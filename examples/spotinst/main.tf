@@ -32,11 +32,11 @@ output "resources" {
 }
 
 output "test_data" {
-  value = "${universe.spotinst_targetset_and_rules.config}"
+  value = "${universe.spotinst_targetset_and_rules.result}"
 }
 
 output "test_targetset_id" {
-  value = "${jsondecode(universe.spotinst_targetset_and_rules.config)["testTargetSet"]}"
+  value = "${universe.spotinst_targetset_and_rules.outputs["testTargetSet"]}"
 }
 
 //output "control_targetset_id" {
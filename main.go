@@ -1,17 +1,24 @@
 package main
 
 import (
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/plugin"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5/tf5server"
 	"github.com/operatorequals/terraform-provider-universe/universe"
+	"os"
 )
 
 func main() {
-	plugin.Serve(&plugin.ServeOpts{
-		ProviderFunc: providerProvider,
-	})
-}
-
-func providerProvider() *schema.Provider {
-	return universe.Provider()
+	if len(os.Args) > 1 && os.Args[1] == "discover" {
+		if err := universe.Discover(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	err := tf5server.Serve("github.com/operatorequals/universe", providerserver.NewProtocol5(universe.Provider()))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,160 @@
+package universe
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// scriptProtocolVersion - The version of the events, stdin and stdout protocol the provider speaks
+const scriptProtocolVersion = 1
+
+// scriptCapabilities - The JSON returned by the script for the 'capabilities' event. A nil
+// *scriptCapabilities means the script did not declare any, and supports everything.
+type scriptCapabilities struct {
+	// Events - the events the script handles, e.g. "create", "read", "update", "delete", "exists", "plan"
+	Events []string `json:"events"`
+	// ProtocolVersion - the protocol version the script was written for
+	ProtocolVersion int `json:"protocol_version"`
+	// Features - the optional provider features the script handles
+	Features []string `json:"features"`
+	// ConfigVersion - the version of the config shape, the 'upgrade' event is run for state with another version
+	ConfigVersion int `json:"config_version"`
+}
+
+// capabilityRequirement - An event or feature the script must support when a resource attribute is set
+type capabilityRequirement struct {
+	Attribute string
+	Event     string
+	Feature   string
+}
+
+// capabilityRequirements - The attributes which depend on the script supporting an event or feature
+var capabilityRequirements = []capabilityRequirement{
+	{Attribute: "plan_event", Event: "plan"},
+	{Attribute: "replace_on_change", Feature: "replace_on_change"},
+	{Attribute: "sensitive_keys", Feature: "sensitive_keys"},
+	{Attribute: "secret_config", Feature: "secrets"},
+	{Attribute: "secret_environment", Feature: "secrets"},
+}
+
+var (
+	capabilitiesCache = map[string]*scriptCapabilities{}
+	capabilitiesMutex sync.Mutex
+)
+
+// supportsEvent - true if the script declared the event, or declared no capabilities
+func (c *scriptCapabilities) supportsEvent(event string) bool {
+	return c == nil || contains(c.Events, event)
+}
+
+// supportsFeature - true if the script declared the feature, or declared no capabilities
+func (c *scriptCapabilities) supportsFeature(feature string) bool {
+	return c == nil || contains(c.Features, feature)
+}
+
+// mutableIDFeature - The feature a script declares when 'update' may return a new id
+const mutableIDFeature = "mutable_id"
+
+// mutableID - true only if the script declared that 'update' may change the id. Otherwise the id is known
+// when planning an update, so resources using it are not replaced.
+func (c *scriptCapabilities) mutableID() bool {
+	return c != nil && contains(c.Features, mutableIDFeature)
+}
+
+// scriptHash - Identify the executor and the content of the script, so an edited script is asked again
+func scriptHash(effectiveDefaults map[string]interface{}) string {
+	h := sha256.New()
+	executor, _ := effectiveDefaults["executor"].(string)
+	script, _ := effectiveDefaults["script"].(string)
+	h.Write([]byte(executor + "\x00" + script + "\x00"))
+	pwd, _ := os.Getwd()
+	if content, err := os.ReadFile(filepath.Join(pwd, script)); err == nil {
+		h.Write(content)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// getCapabilities - Ask the script for its capabilities, once for each version of the script.
+// Scripts which fail the event or do not return a list of events are treated as supporting everything.
+func getCapabilities(effectiveDefaults map[string]interface{}) *scriptCapabilities {
+	hash := scriptHash(effectiveDefaults)
+	capabilitiesMutex.Lock()
+	defer capabilitiesMutex.Unlock()
+	if c, ok := capabilitiesCache[hash]; ok {
+		return c
+	}
+
+	var capabilities *scriptCapabilities
+	rawResponse, err := runScript("capabilities", "", effectiveDefaults, []byte("{}"))
+	if err != nil {
+		log.Printf("getCapabilities() script does not declare capabilities: %#v", err)
+	} else {
+		c := scriptCapabilities{}
+		err = json.Unmarshal(rawResponse, &c)
+		if err != nil || c.Events == nil {
+			log.Printf("getCapabilities() script does not declare capabilities, got: %s", string(rawResponse))
+		} else {
+			log.Printf("getCapabilities() script capabilities: %#v", c)
+			capabilities = &c
+		}
+	}
+	capabilitiesCache[hash] = capabilities
+	return capabilities
+}
+
+// checkCapabilities - Explain which settings of the resource the script cannot handle
+func checkCapabilities(c *scriptCapabilities, d ResourceGetter, providerConfig interface{}) error {
+	if c == nil {
+		return nil
+	}
+	if c.ProtocolVersion > scriptProtocolVersion {
+		return fmt.Errorf("the script needs protocol version %d but the provider supports version %d, upgrade the provider",
+			c.ProtocolVersion, scriptProtocolVersion)
+	}
+	defaults, _ := providerConfig.(map[string]interface{})
+	for _, r := range capabilityRequirements {
+		if !attributeEnabled(r.Attribute, defaults, d) {
+			continue
+		}
+		if r.Event != "" && !c.supportsEvent(r.Event) {
+			return fmt.Errorf("'%s' is set but the script does not support the '%s' event, it supports: %s",
+				r.Attribute, r.Event, strings.Join(c.Events, ", "))
+		}
+		if r.Feature != "" && !c.supportsFeature(r.Feature) {
+			return fmt.Errorf("'%s' is set but the script does not support the '%s' feature, it supports: %s",
+				r.Attribute, r.Feature, strings.Join(c.Features, ", "))
+		}
+	}
+	return nil
+}
+
+// attributeEnabled - true if the attribute is set to a value other than false or empty in the resource or the provider
+func attributeEnabled(name string, defaults map[string]interface{}, d ResourceGetter) bool {
+	enabled := func(v interface{}) bool {
+		switch value := v.(type) {
+		case nil:
+			return false
+		case bool:
+			return value
+		case string:
+			return value != ""
+		case []interface{}:
+			return len(value) > 0
+		case map[string]interface{}:
+			return len(value) > 0
+		}
+		return true
+	}
+	if v, ok := defaults[name]; ok && enabled(v) {
+		return true
+	}
+	v, ok := d.GetOk(name)
+	return ok && enabled(v)
+}
@@ -0,0 +1,100 @@
+package universe
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"strings"
+	"testing"
+)
+
+var testCapabilitiesConfig = map[string]interface{}{
+	"id_key":   "id",
+	"executor": "python3",
+	"script":   "capabilities_test.py",
+}
+
+func Test_getCapabilities(t *testing.T) {
+	c := getCapabilities(map[string]interface{}{"executor": "python3", "script": "resource_universe_test.py", "id_key": "id"})
+	if c != nil || !c.supportsEvent("update") {
+		t.Errorf("expected a script without capabilities to support everything, got %#v", c)
+	}
+	c = getCapabilities(testCapabilitiesConfig)
+	if c == nil || !c.supportsEvent("create") || c.supportsEvent("update") || c.supportsFeature("x") {
+		t.Errorf("unexpected capabilities %#v", c)
+	}
+	if getCapabilities(testCapabilitiesConfig) != c {
+		t.Error("expected the capabilities to be cached")
+	}
+}
+
+func Test_planChangeWithoutUpdate(t *testing.T) {
+	prior := testResource("42", `{"album": "white"}`)
+	replace, err := planChange(prior, testResource("42", `{"album": "black"}`), testCapabilitiesConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !replace {
+		t.Error("expected replacement when the script cannot update")
+	}
+	d := testResource("42", `{"album": "black"}`)
+	d.PlanEvent = types.BoolValue(true)
+	_, err = planChange(prior, d, testCapabilitiesConfig)
+	if err == nil {
+		t.Error("expected plan_event to be rejected")
+	}
+}
+
+func Test_ProviderServerSecretVersionWithoutUpdate(t *testing.T) {
+	s := newTestServer(t)
+	testConfigureServer(t, s)
+	attrs := map[string]tftypes.Value{
+		"config":         testObjectConfig(),
+		"script":         tftypes.NewValue(tftypes.String, "capabilities_test.py"),
+		"secret_version": tftypes.NewValue(tftypes.String, "1"),
+	}
+	created := testCreate(t, s, testServerValue(t, s, attrs))
+	attrs["secret_version"] = tftypes.NewValue(tftypes.String, "2")
+	config := testServerValue(t, s, attrs)
+	plan, err := s.PlanResourceChange(context.Background(), &tfprotov5.PlanResourceChangeRequest{
+		TypeName:         DefaultProviderName,
+		PriorState:       created.NewState,
+		ProposedNewState: testProposed(t, s, created.NewState, config),
+		Config:           config,
+		PriorPrivate:     created.Private,
+	})
+	if err != nil || len(plan.Diagnostics) != 0 {
+		t.Fatalf("plan failed %#v %#v", err, plan.Diagnostics)
+	}
+	if len(plan.RequiresReplace) == 0 {
+		t.Error("expected a change of secret_version to replace a resource the script cannot update")
+	}
+}
+
+func Test_checkCapabilities(t *testing.T) {
+	d := attributeMap{}
+	c := &scriptCapabilities{Events: []string{"create", "plan"}, ProtocolVersion: 1}
+	if err := checkCapabilities(c, d, map[string]interface{}{"plan_event": true}); err != nil {
+		t.Error(err)
+	}
+	c.ProtocolVersion = scriptProtocolVersion + 1
+	if err := checkCapabilities(c, d, nil); err == nil {
+		t.Error("expected a newer protocol to be rejected")
+	}
+	if err := checkCapabilities(nil, d, map[string]interface{}{"plan_event": true}); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_checkCapabilitiesFeatures(t *testing.T) {
+	c := &scriptCapabilities{Events: []string{"create", "read", "update", "delete"}, ProtocolVersion: 1}
+	d := attributeMap{"replace_on_change": []interface{}{"name"}}
+	if err := checkCapabilities(c, d, nil); err == nil || !strings.Contains(err.Error(), "replace_on_change") {
+		t.Errorf("expected 'replace_on_change' to be rejected when the script does not declare its feature %#v", err)
+	}
+	c.Features = []string{"replace_on_change"}
+	if err := checkCapabilities(c, d, nil); err != nil {
+		t.Errorf("expected the declared features to be accepted %#v", err)
+	}
+}
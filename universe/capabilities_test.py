@@ -0,0 +1,15 @@
+import sys
+import json
+
+if __name__ == '__main__':
+    event = sys.argv[1]
+    entre = sys.stdin.read()
+    if event == "capabilities":
+        print(json.dumps({"events": ["create", "read", "delete"], "protocol_version": 1, "features": []}))
+        exit(0)
+    if event in ["update", "exists", "plan"]:
+        print("unexpected event " + event, file=sys.stderr)
+        exit(1)
+    input_dict = json.loads(entre) if entre else {}
+    input_dict.update({"id": "42"})
+    print(json.dumps(input_dict))
@@ -0,0 +1,287 @@
+package universe
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"regexp"
+	"strings"
+)
+
+// decodeHCLConfig - An HCL body. Attributes are values, which cannot refer to variables or call functions.
+// A block is an object under its type and labels, and repeated blocks without labels are a list of objects.
+func decodeHCLConfig(str []byte) (map[string]interface{}, error) {
+	file, diags := hclsyntax.ParseConfig(str, "config", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, hclError(diags)
+	}
+	return hclBodyToJSON(file.Body.(*hclsyntax.Body))
+}
+
+func hclBodyToJSON(body *hclsyntax.Body) (map[string]interface{}, error) {
+	attributes := map[string]interface{}{}
+	for name, attr := range body.Attributes {
+		value, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, hclError(diags)
+		}
+		attributes[name] = hclValueToJSON(value)
+	}
+	unlabeled := map[string]int{}
+	for _, block := range body.Blocks {
+		if _, ok := body.Attributes[block.Type]; ok {
+			return nil, hclRangeError(block.TypeRange, fmt.Errorf("'%s' is both an attribute and a block", block.Type))
+		}
+		content, err := hclBodyToJSON(block.Body)
+		if err != nil {
+			return nil, err
+		}
+		if len(block.Labels) == 0 {
+			unlabeled[block.Type]++
+			switch existing := attributes[block.Type].(type) {
+			case nil:
+				attributes[block.Type] = content
+			case []interface{}:
+				attributes[block.Type] = append(existing, content)
+			case map[string]interface{}:
+				if unlabeled[block.Type] == 1 {
+					return nil, hclRangeError(block.TypeRange, fmt.Errorf("'%s' blocks both with and without labels", block.Type))
+				}
+				attributes[block.Type] = []interface{}{existing, content}
+			}
+			continue
+		}
+		if unlabeled[block.Type] > 0 {
+			return nil, hclRangeError(block.TypeRange, fmt.Errorf("'%s' blocks both with and without labels", block.Type))
+		}
+		parent := attributes
+		for _, key := range append([]string{block.Type}, block.Labels[:len(block.Labels)-1]...) {
+			child, ok := parent[key].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{}
+				parent[key] = child
+			}
+			parent = child
+		}
+		label := block.Labels[len(block.Labels)-1]
+		if _, ok := parent[label]; ok {
+			return nil, hclRangeError(block.LabelRanges[len(block.Labels)-1], fmt.Errorf("duplicate '%s' block '%s'", block.Type, strings.Join(block.Labels, ".")))
+		}
+		parent[label] = content
+	}
+	return attributes, nil
+}
+
+// hclValueToJSON - Convert the value of an attribute, which is always known without variables
+func hclValueToJSON(value cty.Value) interface{} {
+	if value.IsNull() || !value.IsKnown() {
+		return nil
+	}
+	t := value.Type()
+	switch {
+	case t == cty.String:
+		return value.AsString()
+	case t == cty.Number:
+		return json.Number(value.AsBigFloat().Text('g', -1))
+	case t == cty.Bool:
+		return value.True()
+	case t.IsListType() || t.IsTupleType() || t.IsSetType():
+		list := make([]interface{}, 0, value.LengthInt())
+		for it := value.ElementIterator(); it.Next(); {
+			_, element := it.Element()
+			list = append(list, hclValueToJSON(element))
+		}
+		return list
+	}
+	object := map[string]interface{}{}
+	for it := value.ElementIterator(); it.Next(); {
+		key, element := it.Element()
+		object[key.AsString()] = hclValueToJSON(element)
+	}
+	return object
+}
+
+// hclError - The first error of the diagnostics, at the start of its range
+func hclError(diags hcl.Diagnostics) error {
+	for _, diag := range diags {
+		if diag.Severity != hcl.DiagError {
+			continue
+		}
+		err := fmt.Errorf("%s", strings.TrimSuffix(diag.Summary, "."))
+		if diag.Detail != "" {
+			err = fmt.Errorf("%s: %s", strings.TrimSuffix(diag.Summary, "."), diag.Detail)
+		}
+		if diag.Subject == nil {
+			return &configFormatError{Format: configFormatHCL, Err: err}
+		}
+		return hclRangeError(*diag.Subject, err)
+	}
+	return &configFormatError{Format: configFormatHCL, Err: diags}
+}
+
+func hclRangeError(r hcl.Range, err error) error {
+	return &configFormatError{Format: configFormatHCL, Line: r.Start.Line, Column: r.Start.Column, Err: err}
+}
+
+// iniSectionPattern - A section header, e.g. [server] or [server.tls]
+var iniSectionPattern = regexp.MustCompile(`^\[\s*([^\[\]]+?)\s*\]$`)
+
+// decodeINIConfig - An INI file. Keys before the first section are top level, keys in a section are in an
+// object named after it and a dotted section name is nested, e.g. [server.tls]. Values are strings.
+func decodeINIConfig(str []byte) (map[string]interface{}, error) {
+	attributes := map[string]interface{}{}
+	section := attributes
+	lineNumber := 0
+	scanner := bufio.NewScanner(bytes.NewReader(str))
+	for scanner.Scan() {
+		lineNumber++
+		raw := scanner.Text()
+		line := strings.TrimSpace(raw)
+		column := len(raw) - len(strings.TrimLeft(raw, " \t")) + 1
+		iniError := func(err error) error {
+			return &configFormatError{Format: configFormatINI, Line: lineNumber, Column: column, Err: err}
+		}
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if match := iniSectionPattern.FindStringSubmatch(line); match != nil {
+			section = attributes
+			for _, name := range strings.Split(match[1], ".") {
+				child, ok := section[name].(map[string]interface{})
+				if !ok {
+					if _, exists := section[name]; exists {
+						return nil, iniError(fmt.Errorf("section '%s' is also a key", match[1]))
+					}
+					child = map[string]interface{}{}
+					section[name] = child
+				}
+				section = child
+			}
+			continue
+		}
+		separator := strings.IndexAny(line, "=:")
+		if separator <= 0 {
+			return nil, iniError(fmt.Errorf("expected a [section] or key = value"))
+		}
+		key := strings.TrimSpace(line[:separator])
+		if _, exists := section[key]; exists {
+			return nil, iniError(fmt.Errorf("duplicate key '%s'", key))
+		}
+		section[key] = unquoteConfigValue(strings.TrimSpace(line[separator+1:]))
+	}
+	return attributes, scanner.Err()
+}
+
+// unquoteConfigValue - Remove the quotes around a value, e.g. "a b" or 'a b'
+func unquoteConfigValue(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// dotenvKeyPattern - The name of a variable in a dotenv file
+var dotenvKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// decodeDotenvConfig - A dotenv file of KEY=value lines, optionally starting with 'export'. Values are strings:
+// single quoted values are literal, double quoted values may span lines and have escapes like \n, and unquoted
+// values end at a ' #' comment. Variables are not expanded.
+func decodeDotenvConfig(str []byte) (map[string]interface{}, error) {
+	attributes := map[string]interface{}{}
+	lines := strings.Split(strings.ReplaceAll(string(str), "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNumber := i + 1
+		raw := lines[i]
+		line := strings.TrimSpace(raw)
+		column := len(raw) - len(strings.TrimLeft(raw, " \t")) + 1
+		dotenvError := func(err error) error {
+			return &configFormatError{Format: configFormatDotenv, Line: lineNumber, Column: column, Err: err}
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		separator := strings.Index(line, "=")
+		if separator < 0 {
+			return nil, dotenvError(fmt.Errorf("expected KEY=value"))
+		}
+		key := strings.TrimSpace(line[:separator])
+		if !dotenvKeyPattern.MatchString(key) {
+			return nil, dotenvError(fmt.Errorf("invalid variable name '%s'", key))
+		}
+		value := strings.TrimSpace(line[separator+1:])
+		switch {
+		case strings.HasPrefix(value, "'"):
+			end := strings.Index(value[1:], "'")
+			if end < 0 {
+				return nil, dotenvError(fmt.Errorf("unterminated value of '%s'", key))
+			}
+			value = value[1 : end+1]
+		case strings.HasPrefix(value, `"`):
+			quoted := value[1:]
+			for !dotenvClosed(quoted) {
+				if i++; i >= len(lines) {
+					return nil, dotenvError(fmt.Errorf("unterminated value of '%s'", key))
+				}
+				quoted += "\n" + lines[i]
+			}
+			var err error
+			if value, err = dotenvUnescape(quoted); err != nil {
+				return nil, dotenvError(err)
+			}
+		default:
+			if comment := strings.Index(value, " #"); comment >= 0 {
+				value = strings.TrimSpace(value[:comment])
+			}
+		}
+		attributes[key] = value
+	}
+	return attributes, nil
+}
+
+// dotenvClosed - true if the double quoted value has its closing quote
+func dotenvClosed(quoted string) bool {
+	for i := 0; i < len(quoted); i++ {
+		switch quoted[i] {
+		case '\\':
+			i++
+		case '"':
+			return true
+		}
+	}
+	return false
+}
+
+// dotenvUnescape - The double quoted value up to its closing quote, with the escapes replaced
+func dotenvUnescape(quoted string) (string, error) {
+	var value strings.Builder
+	for i := 0; i < len(quoted); i++ {
+		c := quoted[i]
+		switch {
+		case c == '"':
+			if rest := strings.TrimSpace(quoted[i+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+				return "", fmt.Errorf("unexpected '%s' after the closing quote", rest)
+			}
+			return value.String(), nil
+		case c == '\\' && i+1 < len(quoted):
+			i++
+			switch quoted[i] {
+			case 'n':
+				value.WriteByte('\n')
+			case 'r':
+				value.WriteByte('\r')
+			case 't':
+				value.WriteByte('\t')
+			default:
+				value.WriteByte(quoted[i])
+			}
+		default:
+			value.WriteByte(c)
+		}
+	}
+	return value.String(), nil
+}
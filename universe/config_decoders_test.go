@@ -0,0 +1,207 @@
+package universe
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const testAlbumJSON = `{"album": "white", "year": 1968, "tracks": ["Back in the U.S.S.R.", "Dear Prudence"], "label": {"name": "Apple", "country": "UK"}}`
+
+// testAlbumConfigs - The same config in each format with typed values
+var testAlbumConfigs = map[string]string{
+	configFormatJSON: testAlbumJSON,
+	configFormatJSONC: `{
+  // The White Album
+  "album": "white",
+  "year": 1968,
+  "tracks": ["Back in the U.S.S.R.", "Dear Prudence",],
+  "label": {"name": "Apple", "country": "UK"}, /* released in 1968 */
+}`,
+	configFormatJSON5: `// The White Album
+{
+  album: 'white',
+  year: +1968,
+  tracks: ["Back in the U.S.S.R.", 'Dear Prudence',],
+  label: {name: "Apple", "country": 'U\x4B'},
+}`,
+	configFormatYAML: `album: white
+year: 1968
+tracks:
+  - Back in the U.S.S.R.
+  - Dear Prudence
+label:
+  name: Apple
+  country: UK
+`,
+	configFormatTOML: `album = "white"
+year = 1968
+tracks = ["Back in the U.S.S.R.", "Dear Prudence"]
+
+[label]
+name = "Apple"
+country = "UK"
+`,
+	configFormatHCL: `album  = "white"
+year   = 1968
+tracks = ["Back in the U.S.S.R.", "Dear Prudence"]
+
+label {
+  name    = "Apple"
+  country = "UK"
+}
+`,
+}
+
+// testEncoders - Write a decoded config back in its format
+var testEncoders = map[string]func(map[string]interface{}) (string, error){
+	configFormatJSON:   testEncodeJSON,
+	configFormatJSONC:  testEncodeJSON,
+	configFormatJSON5:  testEncodeJSON,
+	configFormatYAML:   func(x map[string]interface{}) (string, error) { return testEncodeString(yamlEncodeCanonical(x)) },
+	configFormatTOML:   func(x map[string]interface{}) (string, error) { return testEncodeString(tomlEncode(x)) },
+	configFormatHCL:    testEncodeHCL,
+	configFormatINI:    testEncodeINI,
+	configFormatDotenv: testEncodeDotenv,
+}
+
+func testEncodeJSON(x map[string]interface{}) (string, error) {
+	b, err := json.Marshal(x)
+	return string(b), err
+}
+
+func testEncodeString(s interface{}, err error) (string, error) {
+	str, _ := s.(string)
+	return str, err
+}
+
+func testEncodeHCL(x map[string]interface{}) (string, error) {
+	f := hclwrite.NewEmptyFile()
+	for _, k := range testSortedKeys(x) {
+		v, err := jsonToCty(x[k])
+		if err != nil {
+			return "", err
+		}
+		f.Body().SetAttributeValue(k, v)
+	}
+	return string(f.Bytes()), nil
+}
+
+func testEncodeINI(x map[string]interface{}) (string, error) {
+	var sb strings.Builder
+	var sections []string
+	for _, k := range testSortedKeys(x) {
+		if _, ok := x[k].(map[string]interface{}); ok {
+			sections = append(sections, k)
+			continue
+		}
+		fmt.Fprintf(&sb, "%s = %v\n", k, x[k])
+	}
+	for _, section := range sections {
+		values := x[section].(map[string]interface{})
+		fmt.Fprintf(&sb, "\n[%s]\n", section)
+		for _, k := range testSortedKeys(values) {
+			fmt.Fprintf(&sb, "%s = %v\n", k, values[k])
+		}
+	}
+	return sb.String(), nil
+}
+
+func testEncodeDotenv(x map[string]interface{}) (string, error) {
+	var sb strings.Builder
+	for _, k := range testSortedKeys(x) {
+		value, _ := json.Marshal(x[k])
+		fmt.Fprintf(&sb, "%s=%s\n", k, value)
+	}
+	return sb.String(), nil
+}
+
+func testSortedKeys(x map[string]interface{}) []string {
+	keys := make([]string, 0, len(x))
+	for k := range x {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// testRoundTrip - Decode the config, check it is the expected JSON, then encode it in the format and decode it again
+func testRoundTrip(t *testing.T, format, config, expected string) {
+	jsonBytes, err := decodeConfigFormat([]byte(config), format)
+	if err != nil || !sameJSON(string(jsonBytes), expected) {
+		t.Errorf("%s: expected %s but got %s %#v", format, expected, jsonBytes, err)
+		return
+	}
+	decoded := map[string]interface{}{}
+	_ = unmarshalWithNumbers(jsonBytes, &decoded)
+	encoded, err := testEncoders[format](decoded)
+	if err != nil {
+		t.Errorf("%s: cannot encode %#v", format, err)
+		return
+	}
+	jsonBytes, err = decodeConfigFormat([]byte(encoded), format)
+	if err != nil || !sameJSON(string(jsonBytes), expected) {
+		t.Errorf("%s: expected %s after the round trip of\n%s\nbut got %s %#v", format, expected, encoded, jsonBytes, err)
+	}
+}
+
+func Test_configFormatsRoundTrip(t *testing.T) {
+	for format, config := range testAlbumConfigs {
+		testRoundTrip(t, format, config, testAlbumJSON)
+	}
+	testRoundTrip(t, configFormatINI, `; The White Album
+album = white
+year = 1968
+
+[label]
+name = "Apple"
+country: UK
+`, `{"album": "white", "year": "1968", "label": {"name": "Apple", "country": "UK"}}`)
+	testRoundTrip(t, configFormatDotenv, `# The White Album
+export ALBUM=white
+YEAR=1968 # released
+LABEL='Apple "Records"'
+NOTES="line 1
+line 2\tend"
+`, `{"ALBUM": "white", "YEAR": "1968", "LABEL": "Apple \"Records\"", "NOTES": "line 1\nline 2\tend"}`)
+	for _, format := range configFormats[1:] {
+		if _, ok := testEncoders[format]; !ok {
+			t.Errorf("no round trip test for the format %s", format)
+		}
+	}
+}
+
+func Test_configFormatsAcceptObjects(t *testing.T) {
+	for _, format := range configFormats {
+		jsonBytes, err := decodeConfigFormat([]byte(testAlbumJSON), format)
+		if err != nil || !sameJSON(string(jsonBytes), testAlbumJSON) {
+			t.Errorf("%s: expected a config written as an object to be accepted %s %#v", format, jsonBytes, err)
+		}
+	}
+}
+
+func Test_configFormatsErrors(t *testing.T) {
+	for _, c := range []struct {
+		format, config string
+		line, column   int
+	}{
+		{configFormatHCL, "album = \"white\"\nyear = \n", 2, 8},
+		{configFormatHCL, "album = var.album\n", 1, 9},
+		{configFormatHCL, "label \"a\" {}\nlabel \"a\" {}\n", 2, 7},
+		{configFormatINI, "album = white\n  year\n", 2, 3},
+		{configFormatINI, "[label]\nname = a\nname = b\n", 3, 1},
+		{configFormatDotenv, "ALBUM=white\n\nYEAR\n", 3, 1},
+		{configFormatDotenv, "ALBUM=white\nNOTES=\"line 1\nline 2\n", 2, 1},
+		{configFormatDotenv, "1ALBUM=white\n", 1, 1},
+	} {
+		_, err := decodeConfigFormat([]byte(c.config), c.format)
+		var formatError *configFormatError
+		if !errors.As(err, &formatError) || formatError.Line != c.line || formatError.Column != c.column {
+			t.Errorf("%s: expected an error at %d:%d in %q but got %#v", c.format, c.line, c.column, c.config, err)
+		}
+	}
+}
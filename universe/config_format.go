@@ -0,0 +1,208 @@
+package universe
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gopkg.in/yaml.v3"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// The values of 'config_format'. With 'auto' JSON, YAML and TOML are tried in turn.
+const (
+	configFormatAuto   = "auto"
+	configFormatJSON   = "json"
+	configFormatJSONC  = "jsonc"
+	configFormatJSON5  = "json5"
+	configFormatYAML   = "yaml"
+	configFormatTOML   = "toml"
+	configFormatHCL    = "hcl"
+	configFormatINI    = "ini"
+	configFormatDotenv = "dotenv"
+)
+
+// configDecoder - Decode a config string into an object of JSON values. A parse error is returned as
+// a *configFormatError holding its position.
+type configDecoder func(str []byte) (map[string]interface{}, error)
+
+// configDecoders - The formats of 'config_format' other than 'auto'. A new format only needs its decoder added here.
+var configDecoders = map[string]configDecoder{
+	configFormatJSON:   decodeJSONConfig,
+	configFormatJSONC:  decodeJSONCConfig,
+	configFormatJSON5:  decodeJSON5Config,
+	configFormatYAML:   decodeYAMLConfig,
+	configFormatTOML:   orJSONObject(decodeTOMLConfig),
+	configFormatHCL:    orJSONObject(decodeHCLConfig),
+	configFormatINI:    orJSONObject(decodeINIConfig),
+	configFormatDotenv: orJSONObject(decodeDotenvConfig),
+}
+
+// configFormats - 'auto' followed by the registered formats, sorted
+var configFormats = configFormatNames()
+
+func configFormatNames() []string {
+	names := make([]string, 0, len(configDecoders))
+	for name := range configDecoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{configFormatAuto}, names...)
+}
+
+// lineNumberPattern - The line reported in YAML and TOML parse errors, e.g. "yaml: line 2: ..." or "Near line 2 ..."
+var lineNumberPattern = regexp.MustCompile(`[Ll]ine (\d+)`)
+
+// linePrefixPattern - The start of a YAML or TOML parse error, repeating the line
+var linePrefixPattern = regexp.MustCompile(`^(yaml: line|Near line) \d+( \(last key parsed '[^']*'\))?: `)
+
+// configFormatError - A config which does not parse in its format, at a line and column when they are known
+type configFormatError struct {
+	Format string
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *configFormatError) Error() string {
+	message := linePrefixPattern.ReplaceAllString(e.Err.Error(), "")
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		return fmt.Sprintf("invalid %s at line %d, column %d: %s", e.Format, e.Line, e.Column, message)
+	case e.Line > 0:
+		return fmt.Sprintf("invalid %s at line %d: %s", e.Format, e.Line, message)
+	}
+	return fmt.Sprintf("invalid %s: %s", e.Format, message)
+}
+
+func (e *configFormatError) Unwrap() error {
+	return e.Err
+}
+
+// getConfigFormat - The format of 'config', 'auto' when it is not set
+func getConfigFormat(d *resourceModel) string {
+	if format := d.ConfigFormat.ValueString(); format != "" {
+		return format
+	}
+	return configFormatAuto
+}
+
+// decodeConfigFormat - Decode a config in the format into JSON. An explicit format is parsed strictly.
+func decodeConfigFormat(str []byte, format string) ([]byte, error) {
+	if format == "" || format == configFormatAuto {
+		return decodeConfigToJSON(str)
+	}
+	decoder, ok := configDecoders[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown config_format '%s', expected one of %v", format, configFormats)
+	}
+	attributes, err := decoder(str)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(attributes)
+}
+
+// orJSONObject - Also accept a JSON object in a format which cannot start with '{', as that is how
+// a config written as an object arrives
+func orJSONObject(decoder configDecoder) configDecoder {
+	return func(str []byte) (map[string]interface{}, error) {
+		if bytes.HasPrefix(bytes.TrimSpace(str), []byte("{")) {
+			return decodeJSONConfig(str)
+		}
+		return decoder(str)
+	}
+}
+
+func decodeYAMLConfig(str []byte) (map[string]interface{}, error) {
+	attributes := map[string]interface{}{}
+	if err := yaml.Unmarshal(str, &attributes); err != nil {
+		return nil, lineError(configFormatYAML, err)
+	}
+	return attributes, nil
+}
+
+func decodeTOMLConfig(str []byte) (map[string]interface{}, error) {
+	attributes := map[string]interface{}{}
+	if _, err := toml.Decode(string(str), &attributes); err != nil {
+		return nil, lineError(configFormatTOML, err)
+	}
+	return attributes, nil
+}
+
+// decodeJSONConfig - Decode a JSON object, reporting the line and column of a syntax error
+func decodeJSONConfig(str []byte) (map[string]interface{}, error) {
+	attributes, offset, err := decodeJSONObject(str)
+	if err != nil {
+		return nil, offsetError(configFormatJSON, str, offset, err)
+	}
+	return attributes, nil
+}
+
+// decodeJSONObject - Decode a JSON object, returning the offset of the byte in error
+func decodeJSONObject(str []byte) (map[string]interface{}, int64, error) {
+	decoder := json.NewDecoder(bytes.NewReader(str))
+	decoder.UseNumber()
+	attributes := map[string]interface{}{}
+	err := decoder.Decode(&attributes)
+	if err == nil && decoder.More() {
+		offset := decoder.InputOffset()
+		offset += int64(len(str[offset:]) - len(bytes.TrimLeft(str[offset:], " \t\r\n")))
+		return nil, offset, fmt.Errorf("unexpected data after the object")
+	}
+	// The offsets of the decoder errors are just after the byte in error
+	var syntaxError *json.SyntaxError
+	var typeError *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxError):
+		return nil, syntaxError.Offset - 1, err
+	case errors.As(err, &typeError):
+		return nil, typeError.Offset - 1, err
+	case err != nil:
+		return nil, int64(len(str)), err
+	}
+	return attributes, 0, nil
+}
+
+// offsetError - A parse error at the offset of a byte, converted to a line and column counted from 1
+func offsetError(format string, str []byte, offset int64, err error) error {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > int64(len(str)) {
+		offset = int64(len(str))
+	}
+	before := str[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return &configFormatError{Format: format, Line: line, Column: column, Err: err}
+}
+
+// lineError - A parse error whose message holds the line, the parsers do not report the column
+func lineError(format string, err error) error {
+	formatError := &configFormatError{Format: format, Err: err}
+	if match := lineNumberPattern.FindStringSubmatch(err.Error()); match != nil {
+		formatError.Line, _ = strconv.Atoi(match[1])
+	}
+	return formatError
+}
+
+// checkConfigFormat - Fail when a config string does not parse in its format, once both are known.
+// A config written as an object is accepted whatever the format.
+func checkConfigFormat(d *resourceModel) error {
+	if d.Config.IsUnknown() || d.ConfigFormat.IsUnknown() {
+		return nil
+	}
+	config, ok := d.Config.UnderlyingValue().(types.String)
+	if !ok || config.IsUnknown() || config.ValueString() == "" {
+		return nil
+	}
+	if _, err := decodeConfigFormat([]byte(config.ValueString()), getConfigFormat(d)); err != nil {
+		return fmt.Errorf("'config': %w", err)
+	}
+	return nil
+}
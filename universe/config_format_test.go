@@ -0,0 +1,102 @@
+package universe
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_decodeConfigFormat(t *testing.T) {
+	for _, c := range []struct {
+		format, config string
+		line, column   int
+	}{
+		{configFormatAuto, "album: white\n", 0, 0},
+		{configFormatJSON, `{"album": "white"}`, 0, 0},
+		{configFormatJSON, "{\n  \"album\": \"white\",\n}", 3, 1},
+		{configFormatJSON, "album: white\n", 1, 1},
+		{configFormatJSON, "{\"album\": \"white\"}\n{}", 2, 1},
+		{configFormatYAML, "album: white\n", 0, 0},
+		{configFormatYAML, "album: white\n  year: 1968\n", 2, 0},
+		{configFormatTOML, "album = \"white\"\n", 0, 0},
+		{configFormatTOML, `{"album": "white"}`, 0, 0},
+		{configFormatTOML, "album = \"white\"\nyear = \n", 2, 0},
+	} {
+		jsonBytes, err := decodeConfigFormat([]byte(c.config), c.format)
+		if c.line == 0 {
+			if err != nil || !sameJSON(string(jsonBytes), `{"album": "white"}`) {
+				t.Errorf("%s: expected the album from %q but got %s %#v", c.format, c.config, jsonBytes, err)
+			}
+			continue
+		}
+		var formatError *configFormatError
+		if !errors.As(err, &formatError) || formatError.Line != c.line || formatError.Column != c.column {
+			t.Errorf("%s: expected an error at %d:%d in %q but got %#v", c.format, c.line, c.column, c.config, err)
+		}
+	}
+}
+
+func Test_checkConfigFormat(t *testing.T) {
+	d := testResource("", `{"album": "white",}`)
+	d.ConfigFormat = types.StringValue(configFormatJSON)
+	if err := checkConfigFormat(d); err == nil || !strings.Contains(err.Error(), "line 1, column 19") {
+		t.Errorf("expected the position of the error but got %#v", err)
+	}
+	d = testResource("", "album: white\n")
+	if err := checkConfigFormat(d); err != nil {
+		t.Errorf("expected YAML to be accepted by default %#v", err)
+	}
+}
+
+func Test_ProviderServerValidateConfigFormat(t *testing.T) {
+	s := newTestServer(t)
+	for format, wantError := range map[string]bool{configFormatJSON: true, configFormatYAML: false} {
+		config := testServerValue(t, s, map[string]tftypes.Value{
+			"config":        tftypes.NewValue(tftypes.String, "album: white\n"),
+			"config_format": tftypes.NewValue(tftypes.String, format),
+		})
+		resp, err := s.ValidateResourceTypeConfig(context.Background(), &tfprotov5.ValidateResourceTypeConfigRequest{
+			TypeName: DefaultProviderName,
+			Config:   config,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !wantError {
+			if len(resp.Diagnostics) != 0 {
+				t.Errorf("%s: expected no diagnostics but got %#v", format, resp.Diagnostics[0])
+			}
+			continue
+		}
+		if len(resp.Diagnostics) != 1 || resp.Diagnostics[0].Attribute == nil || !strings.Contains(resp.Diagnostics[0].Detail, "line 1, column 1") {
+			t.Errorf("%s: expected a diagnostic on 'config' %#v", format, resp.Diagnostics)
+		}
+	}
+	// A config written as an object is accepted whatever the format
+	config := testServerValue(t, s, map[string]tftypes.Value{
+		"config":        testObjectConfig(),
+		"config_format": tftypes.NewValue(tftypes.String, configFormatTOML),
+	})
+	resp, _ := s.ValidateResourceTypeConfig(context.Background(), &tfprotov5.ValidateResourceTypeConfigRequest{
+		TypeName: DefaultProviderName,
+		Config:   config,
+	})
+	if len(resp.Diagnostics) != 0 {
+		t.Errorf("expected an object config to be valid %#v", resp.Diagnostics[0])
+	}
+}
+
+// sameJSON - true if the two strings hold the same JSON document
+func sameJSON(a, b string) bool {
+	var x, y interface{}
+	if json.Unmarshal([]byte(a), &x) != nil || json.Unmarshal([]byte(b), &y) != nil {
+		return false
+	}
+	return reflect.DeepEqual(x, y)
+}
@@ -0,0 +1,260 @@
+package universe
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// decodeJSONCConfig - JSON with comments and trailing commas
+func decodeJSONCConfig(str []byte) (map[string]interface{}, error) {
+	return decodeRelaxedJSON(configFormatJSONC, str, false)
+}
+
+// decodeJSON5Config - JSON5, which adds single quoted strings, unquoted keys and more number forms to JSONC
+func decodeJSON5Config(str []byte) (map[string]interface{}, error) {
+	return decodeRelaxedJSON(configFormatJSON5, str, true)
+}
+
+// decodeRelaxedJSON - Rewrite the document as JSON and decode it. Errors found by the JSON decoder
+// are mapped back to their position in the document.
+func decodeRelaxedJSON(format string, str []byte, json5 bool) (map[string]interface{}, error) {
+	r := &relaxedJSON{src: str, json5: json5}
+	if offset, err := r.rewrite(); err != nil {
+		return nil, offsetError(format, str, int64(offset), err)
+	}
+	attributes, offset, err := decodeJSONObject(r.out)
+	if err != nil {
+		return nil, offsetError(format, str, int64(r.sourceOffset(offset)), err)
+	}
+	return attributes, nil
+}
+
+// relaxedJSON - Rewrites JSONC or JSON5 as JSON, remembering where each byte written came from
+type relaxedJSON struct {
+	src     []byte
+	json5   bool
+	out     []byte
+	offsets []int
+}
+
+func (r *relaxedJSON) emit(from int, s string) {
+	for i := 0; i < len(s); i++ {
+		r.out = append(r.out, s[i])
+		r.offsets = append(r.offsets, from)
+	}
+}
+
+// sourceOffset - The offset in the document of the byte written at offset
+func (r *relaxedJSON) sourceOffset(offset int64) int {
+	switch {
+	case offset < 0:
+		return 0
+	case offset >= int64(len(r.offsets)):
+		return len(r.src)
+	}
+	return r.offsets[offset]
+}
+
+// rewrite - Write the document as JSON into out, returning the offset of anything which cannot be rewritten
+func (r *relaxedJSON) rewrite() (int, error) {
+	src := r.src
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '/' && i+1 < len(src) && (src[i+1] == '/' || src[i+1] == '*'):
+			end, err := r.skipComment(i)
+			if err != nil {
+				return i, err
+			}
+			i = end
+		case c == ',':
+			if next := r.skipSpace(i + 1); next < len(src) && (src[next] == '}' || src[next] == ']') {
+				i++ // A trailing comma
+				continue
+			}
+			r.emit(i, ",")
+			i++
+		case c == '"' || (r.json5 && c == '\''):
+			end, err := r.rewriteString(i)
+			if err != nil {
+				return end, err
+			}
+			i = end
+		case r.json5 && (c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9')):
+			end, err := r.rewriteNumber(i)
+			if err != nil {
+				return i, err
+			}
+			i = end
+		case r.json5 && (c == '_' || c == '$' || isASCIILetter(c)):
+			end := i + 1
+			for end < len(src) && (src[end] == '_' || src[end] == '$' || isASCIILetter(src[end]) || (src[end] >= '0' && src[end] <= '9')) {
+				end++
+			}
+			word := string(src[i:end])
+			switch {
+			case r.skipSpace(end) < len(src) && src[r.skipSpace(end)] == ':':
+				r.emit(i, strconv.Quote(word)) // An unquoted key, which may be a reserved word
+			case word == "Infinity" || word == "NaN":
+				return i, fmt.Errorf("%s cannot be represented in JSON", word)
+			default:
+				r.emit(i, word)
+			}
+			i = end
+		default:
+			r.emit(i, string(c))
+			i++
+		}
+	}
+	return 0, nil
+}
+
+// skipComment - The offset after the comment starting at i
+func (r *relaxedJSON) skipComment(i int) (int, error) {
+	if r.src[i+1] == '/' {
+		end := i + 2
+		for end < len(r.src) && r.src[end] != '\n' {
+			end++
+		}
+		return end, nil
+	}
+	end := strings.Index(string(r.src[i+2:]), "*/")
+	if end < 0 {
+		return i, fmt.Errorf("unterminated comment")
+	}
+	return i + 2 + end + 2, nil
+}
+
+// skipSpace - The offset of the next byte which is not white space or a comment
+func (r *relaxedJSON) skipSpace(i int) int {
+	for i < len(r.src) {
+		switch c := r.src[i]; {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == '/' && i+1 < len(r.src) && (r.src[i+1] == '/' || r.src[i+1] == '*'):
+			end, err := r.skipComment(i)
+			if err != nil {
+				return len(r.src)
+			}
+			i = end
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// rewriteString - Write the string starting at i as a JSON string, returning the offset after it.
+// JSONC strings are copied for the JSON decoder to check, JSON5 strings may be single quoted
+// and have the extra JSON5 escapes.
+func (r *relaxedJSON) rewriteString(i int) (int, error) {
+	src := r.src
+	quote := src[i]
+	r.emit(i, `"`)
+	for j := i + 1; j < len(src); {
+		c := src[j]
+		switch {
+		case c == quote:
+			r.emit(j, `"`)
+			return j + 1, nil
+		case c == '\n' || c == '\r':
+			return j, fmt.Errorf("unterminated string")
+		case c == '"':
+			r.emit(j, `\"`)
+			j++
+		case c == '\\' && j+1 < len(src) && r.json5:
+			n, err := r.rewriteEscape(j)
+			if err != nil {
+				return j, err
+			}
+			j = n
+		case c == '\\' && j+1 < len(src):
+			r.emit(j, string(src[j:j+2]))
+			j += 2
+		default:
+			_, size := utf8.DecodeRune(src[j:])
+			r.emit(j, string(src[j:j+size]))
+			j += size
+		}
+	}
+	return len(src), fmt.Errorf("unterminated string")
+}
+
+// rewriteEscape - Write the JSON5 escape at j as a JSON escape, returning the offset after it
+func (r *relaxedJSON) rewriteEscape(j int) (int, error) {
+	src := r.src
+	switch e := src[j+1]; e {
+	case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+		r.emit(j, string(src[j:j+2]))
+	case 'u':
+		if j+6 > len(src) {
+			return j, fmt.Errorf("invalid escape")
+		}
+		r.emit(j, string(src[j:j+6]))
+		return j + 6, nil
+	case 'x':
+		if j+4 > len(src) {
+			return j, fmt.Errorf("invalid escape")
+		}
+		if _, err := strconv.ParseUint(string(src[j+2:j+4]), 16, 8); err != nil {
+			return j, fmt.Errorf("invalid escape")
+		}
+		r.emit(j, `\u00`+string(src[j+2:j+4]))
+		return j + 4, nil
+	case '0':
+		r.emit(j, `\u0000`)
+	case '\n':
+		// A line continuation
+	case '\r':
+		if j+2 < len(src) && src[j+2] == '\n' {
+			return j + 3, nil
+		}
+	case 'v':
+		r.emit(j, `\u000b`)
+	default:
+		r.emit(j, string(e)) // Any other escaped character is itself, e.g. \'
+	}
+	return j + 2, nil
+}
+
+// rewriteNumber - Write the JSON5 number starting at i as a JSON number, returning the offset after it
+func (r *relaxedJSON) rewriteNumber(i int) (int, error) {
+	src := r.src
+	end := i
+	for end < len(src) && strings.IndexByte("+-.0123456789abcdefABCDEFxXInfinityNaN", src[end]) >= 0 {
+		end++
+	}
+	number := string(src[i:end])
+	sign := ""
+	switch {
+	case strings.HasPrefix(number, "-"):
+		sign, number = "-", number[1:]
+	case strings.HasPrefix(number, "+"):
+		number = number[1:]
+	}
+	switch {
+	case number == "Infinity" || number == "NaN":
+		return i, fmt.Errorf("%s cannot be represented in JSON", number)
+	case strings.HasPrefix(number, "0x") || strings.HasPrefix(number, "0X"):
+		n, err := strconv.ParseUint(number[2:], 16, 64)
+		if err != nil {
+			return i, fmt.Errorf("invalid hexadecimal number '%s'", src[i:end])
+		}
+		number = strconv.FormatUint(n, 10)
+	default:
+		if strings.HasPrefix(number, ".") {
+			number = "0" + number
+		}
+		number = strings.Replace(number, ".e", "e", 1)
+		number = strings.Replace(number, ".E", "E", 1)
+		number = strings.TrimSuffix(number, ".")
+	}
+	r.emit(i, sign+number)
+	return end, nil
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
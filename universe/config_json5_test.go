@@ -0,0 +1,41 @@
+package universe
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_decodeJSON5Config(t *testing.T) {
+	for config, expected := range map[string]string{
+		`{hex: 0x1F, half: .5, whole: 5., small: -.5e-1, signed: +1}`:              `{"hex": 31, "half": 0.5, "whole": 5, "small": -0.05, "signed": 1}`,
+		`{'quote': 'it\'s "here"', $key_2: "\x41\0"}`:                              `{"quote": "it's \"here\"", "$key_2": "A\u0000"}`,
+		"{long: 'one \\\ntwo', url: 'http://a/b' /* not a comment in a string */}": `{"long": "one two", "url": "http://a/b"}`,
+		`{"list": [1, 2, ], "nested": {"a": null, }, }`:                            `{"list": [1, 2], "nested": {"a": null}}`,
+		`{true: 1, null : false, NaN: null}`:                                       `{"true": 1, "null": false, "NaN": null}`,
+	} {
+		jsonBytes, err := decodeConfigFormat([]byte(config), configFormatJSON5)
+		if err != nil || !sameJSON(string(jsonBytes), expected) {
+			t.Errorf("expected %s from %s but got %s %#v", expected, config, jsonBytes, err)
+		}
+	}
+}
+
+func Test_decodeRelaxedJSONErrors(t *testing.T) {
+	for _, c := range []struct {
+		format, config string
+		line, column   int
+	}{
+		{configFormatJSONC, "{\n  // a comment\n  album: \"white\"\n}", 3, 3},
+		{configFormatJSONC, "{\"album\": 'white'}", 1, 11},
+		{configFormatJSONC, "{\"album\": \"white\" /* unterminated", 1, 19},
+		{configFormatJSON5, "{\n  album: 'white',\n  year: 19 68,\n}", 3, 12},
+		{configFormatJSON5, "{\n  year: Infinity\n}", 2, 9},
+		{configFormatJSON5, "{album: 'white\n'}", 1, 15},
+	} {
+		_, err := decodeConfigFormat([]byte(c.config), c.format)
+		var formatError *configFormatError
+		if !errors.As(err, &formatError) || formatError.Line != c.line || formatError.Column != c.column {
+			t.Errorf("%s: expected an error at %d:%d in %q but got %#v", c.format, c.line, c.column, c.config, err)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package universe
+
+import (
+	"fmt"
+	"log"
+)
+
+const (
+	// onDestroyDelete - Destroying the resource runs the 'delete' event, the default
+	onDestroyDelete = "delete"
+	// onDestroyAbandon - Destroying the resource only removes it from the state, the object is left as it is
+	onDestroyAbandon = "abandon"
+)
+
+// deletionPolicyAttributes - Changing these alone does not run the 'update' event
+var deletionPolicyAttributes = []string{"deletion_protection", "on_destroy"}
+
+// deletionProtectedError - The error for destroying or replacing a protected resource
+func deletionProtectedError(id string) error {
+	return fmt.Errorf("the resource '%s' has deletion_protection set. Set it to false and apply before destroying or replacing it", id)
+}
+
+// checkDeletionPolicy - Fail for protected resources, and tell whether the script should be run to delete the object
+func checkDeletionPolicy(d *resourceModel) (bool, error) {
+	if d.DeletionProtection.ValueBool() {
+		return false, deletionProtectedError(d.ID.ValueString())
+	}
+	if d.OnDestroy.ValueString() == onDestroyAbandon {
+		log.Printf("checkDeletionPolicy() abandoning '%s', the object is not deleted", d.ID.ValueString())
+		return false, nil
+	}
+	return true, nil
+}
@@ -0,0 +1,44 @@
+package universe
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"testing"
+)
+
+func Test_checkDeletionPolicy(t *testing.T) {
+	d := testResource("42", `{"album": "white"}`)
+	if run, err := checkDeletionPolicy(d); !run || err != nil {
+		t.Errorf("expected delete by default %v %#v", run, err)
+	}
+	d.OnDestroy = types.StringValue(onDestroyAbandon)
+	if run, err := checkDeletionPolicy(d); run || err != nil {
+		t.Errorf("expected abandon %v %#v", run, err)
+	}
+	d.DeletionProtection = types.BoolValue(true)
+	if _, err := checkDeletionPolicy(d); err == nil {
+		t.Error("expected protected resources to fail")
+	}
+}
+
+func Test_ProviderServerDeletionProtection(t *testing.T) {
+	s := newTestServer(t)
+	testConfigureServer(t, s)
+	prior := testServerValue(t, s, map[string]tftypes.Value{
+		"id":                  tftypes.NewValue(tftypes.String, "42"),
+		"config":              testObjectConfig(),
+		"deletion_protection": tftypes.NewValue(tftypes.Bool, true),
+	})
+	proposedNull, _ := tfprotov5.NewDynamicValue(s.resourceType, tftypes.NewValue(s.resourceType, nil))
+	plan, err := s.PlanResourceChange(context.Background(), &tfprotov5.PlanResourceChangeRequest{
+		TypeName:         DefaultProviderName,
+		PriorState:       prior,
+		ProposedNewState: &proposedNull,
+		Config:           &proposedNull,
+	})
+	if err != nil || len(plan.Diagnostics) != 1 || plan.Diagnostics[0].Summary != "Deletion protection" {
+		t.Errorf("expected destroying to fail %#v %#v", err, plan.Diagnostics)
+	}
+}
@@ -0,0 +1,159 @@
+package universe
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// stringsFlag - A flag which may be repeated, e.g. --env a=1 --env b=2
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// Discover - The 'discover' subcommand. Runs the script's 'list' event and writes Terraform
+// import blocks with matching resource blocks for the existing objects.
+func Discover(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("discover", flag.ContinueOnError)
+	flags.SetOutput(stdout)
+	resourceType := flags.String("type", "", "the resource type to discover, e.g. json_file")
+	executor := flags.String("executor", "", "the name of the program to run. e.g. python")
+	script := flags.String("script", "", "the path to the script passed as the first argument to 'executor'")
+	idKey := flags.String("id-key", "id", "the name of the key which holds the unique identifier of the resource")
+	out := flags.String("out", "discovered.tf", "the file to write, or '-' for standard output")
+	var environment stringsFlag
+	flags.Var(&environment, "env", "an environment variable for the script as name=value, may be repeated")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *resourceType == "" || *executor == "" || *script == "" {
+		return fmt.Errorf("discover needs --type, --executor and --script")
+	}
+
+	providerName := getProviderNameFromBinaryOrEnvironment()
+	typeName, err := resolveResourceTypeName(providerName, *resourceType)
+	if err != nil {
+		return err
+	}
+	env := map[string]interface{}{}
+	for _, e := range environment {
+		kv := strings.SplitN(e, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("expected name=value in --env but got '%s'", e)
+		}
+		env[kv[0]] = kv[1]
+	}
+	effectiveDefaults := map[string]interface{}{
+		"executor":    *executor,
+		"script":      *script,
+		"id_key":      *idKey,
+		"environment": env,
+	}
+	entries, err := callList(effectiveDefaults, nil)
+	if err != nil {
+		return err
+	}
+	hclBytes, err := generateImportBlocks(typeName, effectiveDefaults, entries)
+	if err != nil {
+		return err
+	}
+	if *out == "-" {
+		_, err = stdout.Write(hclBytes)
+		return err
+	}
+	err = os.WriteFile(*out, hclBytes, 0644)
+	if err == nil {
+		_, err = fmt.Fprintf(stdout, "wrote %d resources to %s\n", len(entries), *out)
+	}
+	return err
+}
+
+// resolveResourceTypeName - Find the resource type in the types the provider would register
+func resolveResourceTypeName(providerName, resourceType string) (string, error) {
+	types := getResourceTypeNamesFromEnvironment(providerName)
+	for _, name := range []string{resourceType, providerName + "_" + resourceType} {
+		if types[name] {
+			return name, nil
+		}
+	}
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	return "", fmt.Errorf("unknown resource type '%s', the provider has: %s", resourceType, strings.Join(names, ", "))
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// resourceName - Make a Terraform resource name from the id, unique amongst the names used so far
+func resourceName(id string, used map[string]bool) string {
+	name := strings.Trim(invalidNameChars.ReplaceAllString(id, "_"), "_-")
+	if name == "" || !(name[0] == '_' || (name[0] >= 'a' && name[0] <= 'z') || (name[0] >= 'A' && name[0] <= 'Z')) {
+		name = "r_" + name
+	}
+	unique := name
+	for i := 2; used[unique]; i++ {
+		unique = fmt.Sprintf("%s_%d", name, i)
+	}
+	used[unique] = true
+	return unique
+}
+
+// generateImportBlocks - Write an import block and a resource block for each object
+func generateImportBlocks(typeName string, effectiveDefaults map[string]interface{}, entries []listEntry) ([]byte, error) {
+	f := hclwrite.NewEmptyFile()
+	body := f.Body()
+	used := map[string]bool{}
+	for n, entry := range entries {
+		name := resourceName(entry.ID, used)
+		if n > 0 {
+			body.AppendNewline()
+		}
+		importBlock := body.AppendNewBlock("import", nil).Body()
+		importBlock.SetAttributeTraversal("to", hcl.Traversal{
+			hcl.TraverseRoot{Name: typeName},
+			hcl.TraverseAttr{Name: name},
+		})
+		importBlock.SetAttributeValue("id", cty.StringVal(entry.ID))
+		body.AppendNewline()
+
+		resourceBlock := body.AppendNewBlock("resource", []string{typeName, name}).Body()
+		for _, key := range []string{"executor", "script", "id_key"} {
+			resourceBlock.SetAttributeValue(key, cty.StringVal(effectiveDefaults[key].(string)))
+		}
+		config, err := jsonToCty(entry.Config)
+		if err != nil {
+			return nil, err
+		}
+		resourceBlock.SetAttributeValue("config", config)
+	}
+	return f.Bytes(), nil
+}
+
+// jsonToCty - Convert a value decoded from JSON into a cty value for writing HCL
+func jsonToCty(v interface{}) (cty.Value, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	t, err := ctyjson.ImpliedType(b)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	return ctyjson.Unmarshal(b, t)
+}
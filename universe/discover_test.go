@@ -0,0 +1,96 @@
+package universe
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func Test_callList(t *testing.T) {
+	entries, err := callList(map[string]interface{}{"id_key": "id", "executor": "python3", "script": "resource_universe_test.py"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []listEntry{
+		{ID: "42", Config: map[string]interface{}{"album": "white"}},
+		{ID: "43", Config: map[string]interface{}{"id": "43", "album": "abbey road"}},
+	}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("unexpected entries %#v", entries)
+	}
+}
+
+func Test_Discover(t *testing.T) {
+	var out bytes.Buffer
+	err := Discover([]string{"--type", "universe", "--executor", "python3", "--script", "resource_universe_test.py", "--out", "-"}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `import {
+  to = universe.r_42
+  id = "42"
+}
+
+resource "universe" "r_42" {
+  executor = "python3"
+  script   = "resource_universe_test.py"
+  id_key   = "id"
+  config = {
+    album = "white"
+  }
+}
+
+import {
+  to = universe.r_43
+  id = "43"
+}
+
+resource "universe" "r_43" {
+  executor = "python3"
+  script   = "resource_universe_test.py"
+  id_key   = "id"
+  config = {
+    album = "abbey road"
+    id    = "43"
+  }
+}
+`
+	if out.String() != expected {
+		t.Errorf("unexpected HCL:\n%s", out.String())
+	}
+	if err = Discover([]string{"--type", "nothing", "--executor", "python3", "--script", "x"}, &out); err == nil {
+		t.Error("expected an unknown type to fail")
+	}
+}
+
+func Test_resourceName(t *testing.T) {
+	used := map[string]bool{}
+	for _, c := range [][2]string{{"/tmp/a.json", "tmp_a_json"}, {"/tmp/a:json", "tmp_a_json_2"}, {"9", "r_9"}} {
+		if name := resourceName(c[0], used); name != c[1] {
+			t.Errorf("expected %s but got %s", c[1], name)
+		}
+	}
+}
+
+func Test_makeListEntry(t *testing.T) {
+	defaults := map[string]interface{}{"id_key": "id"}
+	entry, err := makeListEntry(map[string]interface{}{"id": json.Number("42"), "config": map[string]interface{}{"album": "white"}}, defaults)
+	if err != nil || entry.ID != "42" {
+		t.Errorf("expected the numeric id of a wrapped entry %#v %#v", entry, err)
+	}
+	entry, err = makeListEntry(map[string]interface{}{"id": json.Number("43"), "album": "abbey road"}, defaults)
+	if err != nil || entry.ID != "43" {
+		t.Errorf("expected the numeric id of an entry %#v %#v", entry, err)
+	}
+	for _, element := range []map[string]interface{}{
+		{"config": map[string]interface{}{"album": "white"}},
+		{"id": nil, "config": map[string]interface{}{"album": "white"}},
+		{"id": true, "config": map[string]interface{}{"album": "white"}},
+		{"album": "white"},
+	} {
+		if _, err = makeListEntry(element, defaults); err == nil {
+			t.Errorf("expected an error for the element without an id %#v", element)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package universe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"math/big"
+)
+
+// valueToJSON - Convert a fully known Terraform value into the Go types produced by encoding/json.
+func valueToJSON(v tftypes.Value) (interface{}, error) {
+	if !v.IsFullyKnown() {
+		return nil, fmt.Errorf("value is not fully known: %s", v)
+	}
+	if v.IsNull() {
+		return nil, nil
+	}
+	typ := v.Type()
+	switch {
+	case typ.Is(tftypes.String):
+		var s string
+		err := v.As(&s)
+		return s, err
+	case typ.Is(tftypes.Bool):
+		var b bool
+		err := v.As(&b)
+		return b, err
+	case typ.Is(tftypes.Number):
+		f := new(big.Float)
+		err := v.As(&f)
+		if err != nil {
+			return nil, err
+		}
+		if f.IsInt() {
+			return json.Number(f.Text('f', 0)), nil
+		}
+		return json.Number(f.Text('g', -1)), nil
+	case typ.Is(tftypes.Object{}), typ.Is(tftypes.Map{}):
+		attrs := map[string]tftypes.Value{}
+		err := v.As(&attrs)
+		if err != nil {
+			return nil, err
+		}
+		result := make(map[string]interface{}, len(attrs))
+		for k, a := range attrs {
+			result[k], err = valueToJSON(a)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	case typ.Is(tftypes.List{}), typ.Is(tftypes.Set{}), typ.Is(tftypes.Tuple{}):
+		var elems []tftypes.Value
+		err := v.As(&elems)
+		if err != nil {
+			return nil, err
+		}
+		result := make([]interface{}, len(elems))
+		for i, e := range elems {
+			result[i], err = valueToJSON(e)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", typ)
+}
+
+// jsonToValue - Convert the Go types produced by encoding/json into a Terraform value.
+// The types are inferred as jsondecode() does: objects for maps and tuples for arrays.
+// The unknown marker becomes an unknown value.
+func jsonToValue(x interface{}) (tftypes.Value, error) {
+	switch v := x.(type) {
+	case nil:
+		return tftypes.NewValue(tftypes.DynamicPseudoType, nil), nil
+	case string:
+		if v == unknownVariableValue {
+			return tftypes.NewValue(tftypes.DynamicPseudoType, tftypes.UnknownValue), nil
+		}
+		return tftypes.NewValue(tftypes.String, v), nil
+	case bool:
+		return tftypes.NewValue(tftypes.Bool, v), nil
+	case float64:
+		return tftypes.NewValue(tftypes.Number, big.NewFloat(v)), nil
+	case json.Number:
+		f, _, err := big.ParseFloat(string(v), 10, 512, big.ToNearestEven)
+		if err != nil {
+			return tftypes.Value{}, err
+		}
+		return tftypes.NewValue(tftypes.Number, f), nil
+	case map[string]interface{}:
+		types := make(map[string]tftypes.Type, len(v))
+		values := make(map[string]tftypes.Value, len(v))
+		for k, e := range v {
+			ev, err := jsonToValue(e)
+			if err != nil {
+				return tftypes.Value{}, err
+			}
+			types[k] = ev.Type()
+			values[k] = ev
+		}
+		return tftypes.NewValue(tftypes.Object{AttributeTypes: types}, values), nil
+	case []interface{}:
+		types := make([]tftypes.Type, len(v))
+		values := make([]tftypes.Value, len(v))
+		for i, e := range v {
+			ev, err := jsonToValue(e)
+			if err != nil {
+				return tftypes.Value{}, err
+			}
+			types[i] = ev.Type()
+			values[i] = ev
+		}
+		return tftypes.NewValue(tftypes.Tuple{ElementTypes: types}, values), nil
+	}
+	return tftypes.Value{}, fmt.Errorf("unsupported JSON value %#v", x)
+}
+
+// dynamicToJSON - Convert a fully known dynamic value, e.g. 'config' written as an object, into the Go types
+// produced by encoding/json
+func dynamicToJSON(v types.Dynamic) (interface{}, error) {
+	value, err := v.ToTerraformValue(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return valueToJSON(value)
+}
+
+// jsonToDynamic - Convert the Go types produced by encoding/json into a dynamic value, e.g. the response in 'result'
+func jsonToDynamic(x interface{}) (types.Dynamic, error) {
+	value, err := jsonToValue(x)
+	if err != nil {
+		return types.DynamicNull(), err
+	}
+	dynamic, err := types.DynamicType.ValueFromTerraform(context.Background(), value)
+	if err != nil {
+		return types.DynamicNull(), err
+	}
+	return dynamic.(types.Dynamic), nil
+}
@@ -0,0 +1,247 @@
+package universe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"log"
+	"time"
+)
+
+// ephemeralResource - The ephemeral resource of a resource type. The script gets 'config' on stdin for the
+// 'open' event and its response is in 'result', which Terraform never writes to the plan or state.
+type ephemeralResource struct {
+	typeName string
+	// providerConfig - the provider configuration, once the provider is configured
+	providerConfig interface{}
+}
+
+var (
+	_ ephemeral.EphemeralResourceWithConfigure = &ephemeralResource{}
+	_ ephemeral.EphemeralResourceWithRenew     = &ephemeralResource{}
+	_ ephemeral.EphemeralResourceWithClose     = &ephemeralResource{}
+)
+
+func (e *ephemeralResource) Metadata(_ context.Context, _ ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = e.typeName
+}
+
+func (e *ephemeralResource) Schema(_ context.Context, _ ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = ephemeralResourceSchema()
+}
+
+func (e *ephemeralResource) Configure(_ context.Context, req ephemeral.ConfigureRequest, _ *ephemeral.ConfigureResponse) {
+	if req.ProviderData != nil {
+		e.providerConfig = req.ProviderData
+	}
+}
+
+// ephemeralResourceSchema - The schema of the ephemeral resource of every resource type
+func ephemeralResourceSchema() schema.Schema {
+	return schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"executor": schema.StringAttribute{Optional: true, Description: "The name of the program to run. e.g. python"},
+			"script":   schema.StringAttribute{Optional: true, Description: "The path to the script passed as the first argument to 'executor'."},
+			"id_key":   schema.StringAttribute{Optional: true, Description: "The name of the key which holds the unique identifier of the resource. e.g. 'id'"},
+			"config": schema.DynamicAttribute{
+				Optional:    true,
+				Description: "An object, or a JSON/YAML/TOML string, passed to the script on stdin for the 'open' event.",
+			},
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "The value of the 'id_key' fields in the response of the 'open' event, if any.",
+			},
+			"result": schema.DynamicAttribute{
+				Computed:    true,
+				Description: "The JSON object returned by the script for the 'open' event.",
+			},
+		},
+	}
+}
+
+// ephemeralPrivate - What the 'renew' and 'close' events need, kept by Terraform in memory for the run.
+// Terraform only sends the type name and this back, so the script settings of the block are kept too.
+type ephemeralPrivate struct {
+	Executor string `json:"executor,omitempty"`
+	Script   string `json:"script,omitempty"`
+	IDKey    string `json:"id_key,omitempty"`
+	ID       string `json:"id,omitempty"`
+	// Config - the config sent to 'open', sent again on stdin to 'renew' and 'close'
+	Config json.RawMessage `json:"config,omitempty"`
+	// Private - the '_private' object returned by the script, passed back in UNIVERSE_PRIVATE
+	Private json.RawMessage `json:"private,omitempty"`
+}
+
+// ephemeralModel - An ephemeral resource block and its result
+type ephemeralModel struct {
+	Executor types.String  `tfsdk:"executor"`
+	Script   types.String  `tfsdk:"script"`
+	IDKey    types.String  `tfsdk:"id_key"`
+	Config   types.Dynamic `tfsdk:"config"`
+	ID       types.String  `tfsdk:"id"`
+	Result   types.Dynamic `tfsdk:"result"`
+}
+
+// ephemeralDefaults - The effective defaults for an event of an ephemeral resource, with the '_private' object
+// of the script in its secret environment, which is not logged
+func (e *ephemeralResource) ephemeralDefaults(event string, p ephemeralPrivate) (map[string]interface{}, error) {
+	config := attributeMap{}
+	for name, value := range map[string]string{"executor": p.Executor, "script": p.Script, "id_key": p.IDKey} {
+		if value != "" {
+			config[name] = value
+		}
+	}
+	providerConfig := e.providerConfig
+	if len(p.Private) > 0 {
+		providerConfig = withSecretEnvironment(providerConfig, map[string]string{scriptPrivateVar: string(p.Private)})
+	}
+	effectiveDefaults, _, err := extractEssentialFields(event, config, providerConfig)
+	return effectiveDefaults, err
+}
+
+// callEphemeral - Run an event of an ephemeral resource. The response may hold a '_private' object for later
+// events and '_renew_at', an RFC 3339 time when the 'renew' event should be run.
+func callEphemeral(event string, effectiveDefaults map[string]interface{}, p *ephemeralPrivate) (map[string]interface{}, time.Time, error) {
+	var renewAt time.Time
+	rawResponse, err := runScript(event, p.ID, effectiveDefaults, p.Config)
+	if err != nil {
+		return nil, renewAt, err
+	}
+	if event == "close" {
+		return nil, renewAt, nil
+	}
+	response, err := jsonSafeUnmarshal(rawResponse, nil)
+	if err != nil {
+		return nil, renewAt, fmt.Errorf("expecting JSON from the %s event: %w", event, err)
+	}
+	responseMap, ok := response.(map[string]interface{})
+	if !ok {
+		if response == nil && event == "renew" {
+			return nil, renewAt, nil
+		}
+		return nil, renewAt, fmt.Errorf("expecting an object from the %s event", event)
+	}
+	if v, ok := responseMap["_private"]; ok {
+		delete(responseMap, "_private")
+		if p.Private, err = json.Marshal(v); err != nil {
+			return nil, renewAt, err
+		}
+	}
+	if v, ok := responseMap["_renew_at"]; ok {
+		delete(responseMap, "_renew_at")
+		str, _ := v.(string)
+		if renewAt, err = time.Parse(time.RFC3339, str); err != nil {
+			return nil, renewAt, fmt.Errorf("expecting an RFC 3339 time in '_renew_at' from the %s event: %w", event, err)
+		}
+	}
+	return responseMap, renewAt, nil
+}
+
+// Open - Run the script's 'open' event and return its response as the result
+func (e *ephemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	var m ephemeralModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &m)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	p := ephemeralPrivate{Executor: m.Executor.ValueString(), Script: m.Script.ValueString(), IDKey: m.IDKey.ValueString()}
+	p.Config = []byte("{}")
+	if !m.Config.IsNull() && !m.Config.IsUnderlyingValueNull() {
+		var err error
+		if p.Config, err = configJSON(m.Config, configFormatAuto); err != nil {
+			resp.Diagnostics.AddError("Invalid ephemeral resource config", err.Error())
+			return
+		}
+	}
+	effectiveDefaults, err := e.ephemeralDefaults("open", p)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid ephemeral resource config", err.Error())
+		return
+	}
+	response, renewAt, err := callEphemeral("open", effectiveDefaults, &p)
+	if err != nil {
+		resp.Diagnostics.AddError("Open failed", err.Error())
+		return
+	}
+	m.ID = types.StringNull()
+	if id, err := idFromResponse(response, effectiveDefaults); err == nil {
+		p.ID = id
+		m.ID = types.StringValue(id)
+	}
+	if m.Result, err = jsonToDynamic(response); err != nil {
+		resp.Diagnostics.AddError("Invalid ephemeral resource result", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &m)...)
+	resp.RenewAt = renewAt
+	resp.Diagnostics.Append(setEphemeralPrivate(ctx, resp.Private, p)...)
+}
+
+// Renew - Run the script's 'renew' event when it asked for it with '_renew_at'
+func (e *ephemeralResource) Renew(ctx context.Context, req ephemeral.RenewRequest, resp *ephemeral.RenewResponse) {
+	p, effectiveDefaults, err := e.ephemeralEvent(ctx, "renew", req.Private)
+	if err != nil {
+		resp.Diagnostics.AddError("Renew failed", err.Error())
+		return
+	}
+	if _, resp.RenewAt, err = callEphemeral("renew", effectiveDefaults, &p); err != nil {
+		resp.Diagnostics.AddError("Renew failed", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(setEphemeralPrivate(ctx, resp.Private, p)...)
+}
+
+// Close - Run the script's 'close' event, e.g. to revoke a token
+func (e *ephemeralResource) Close(ctx context.Context, req ephemeral.CloseRequest, resp *ephemeral.CloseResponse) {
+	p, effectiveDefaults, err := e.ephemeralEvent(ctx, "close", req.Private)
+	if err != nil {
+		resp.Diagnostics.AddError("Close failed", err.Error())
+		return
+	}
+	if effectiveDefaults == nil {
+		return
+	}
+	if _, _, err = callEphemeral("close", effectiveDefaults, &p); err != nil {
+		resp.Diagnostics.AddError("Close failed", err.Error())
+	}
+}
+
+// setEphemeralPrivate - Keep what the 'renew' and 'close' events need in the private data of the ephemeral resource
+func setEphemeralPrivate(ctx context.Context, private privateData, p ephemeralPrivate) diag.Diagnostics {
+	data, err := json.Marshal(p)
+	if err != nil {
+		var diags diag.Diagnostics
+		diags.AddError("Invalid ephemeral resource result", err.Error())
+		return diags
+	}
+	return private.SetKey(ctx, privateKey, data)
+}
+
+// ephemeralEvent - Decode the private data of an ephemeral resource for the 'renew' or 'close' event.
+// The effective defaults are nil when the script does not handle the event.
+func (e *ephemeralResource) ephemeralEvent(ctx context.Context, event string, private privateData) (ephemeralPrivate, map[string]interface{}, error) {
+	p := ephemeralPrivate{}
+	data, diags := private.GetKey(ctx, privateKey)
+	if diags.HasError() {
+		return p, nil, fmt.Errorf("reading the private data: %s", diags[0].Detail())
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return p, nil, err
+	}
+	effectiveDefaults, err := e.ephemeralDefaults(event, p)
+	if err != nil {
+		return p, nil, err
+	}
+	if !getCapabilities(effectiveDefaults).supportsEvent(event) {
+		log.Printf("ephemeralEvent() the script has no '%s' event", event)
+		if event == "renew" {
+			return p, nil, fmt.Errorf("the script asked to be renewed but has no 'renew' event")
+		}
+		return p, nil, nil
+	}
+	return p, effectiveDefaults, nil
+}
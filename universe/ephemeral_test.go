@@ -0,0 +1,97 @@
+package universe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testEphemeralConfig(t *testing.T) *tfprotov5.DynamicValue {
+	objectType := ephemeralResourceSchema().Type().TerraformType(context.Background())
+	values := map[string]tftypes.Value{}
+	for name, typ := range objectType.(tftypes.Object).AttributeTypes {
+		values[name] = tftypes.NewValue(typ, nil)
+	}
+	values["config"] = testObjectConfig()
+	dv, err := tfprotov5.NewDynamicValue(objectType, tftypes.NewValue(objectType, values))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &dv
+}
+
+func Test_ProviderServerEphemeralResource(t *testing.T) {
+	s := newTestServer(t)
+	testConfigureServer(t, s)
+	schemaResp, _ := s.GetProviderSchema(context.Background(), &tfprotov5.GetProviderSchemaRequest{})
+	if _, ok := schemaResp.EphemeralResourceSchemas[DefaultProviderName]; !ok {
+		t.Errorf("expected an ephemeral resource schema")
+	}
+	config := testEphemeralConfig(t)
+	validate, err := s.ValidateEphemeralResourceConfig(context.Background(), &tfprotov5.ValidateEphemeralResourceConfigRequest{
+		TypeName: DefaultProviderName,
+		Config:   config,
+	})
+	if err != nil || len(validate.Diagnostics) != 0 {
+		t.Fatalf("validate failed %#v %#v", err, validate.Diagnostics)
+	}
+
+	open, err := s.OpenEphemeralResource(context.Background(), &tfprotov5.OpenEphemeralResourceRequest{
+		TypeName: DefaultProviderName,
+		Config:   config,
+	})
+	if err != nil || len(open.Diagnostics) != 0 {
+		t.Fatalf("open failed %#v %#v", err, open.Diagnostics)
+	}
+	if !open.RenewAt.Equal(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected renew at %s", open.RenewAt)
+	}
+	value, err := open.Result.Unmarshal(ephemeralResourceSchema().Type().TerraformType(context.Background()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	attrs := map[string]tftypes.Value{}
+	_ = value.As(&attrs)
+	result := map[string]tftypes.Value{}
+	_ = attrs["result"].As(&result)
+	if !attrs["id"].Equal(tftypes.NewValue(tftypes.String, "e1")) || !result["token"].Equal(tftypes.NewValue(tftypes.String, "t-x")) {
+		t.Errorf("unexpected result %s", value)
+	}
+	if _, ok := result["_private"]; ok {
+		t.Errorf("expected no _private in the result %s", value)
+	}
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	renew, err := s.RenewEphemeralResource(context.Background(), &tfprotov5.RenewEphemeralResourceRequest{
+		TypeName: DefaultProviderName,
+		Private:  open.Private,
+	})
+	if err != nil || len(renew.Diagnostics) != 0 {
+		t.Fatalf("renew failed %#v %#v", err, renew.Diagnostics)
+	}
+	if strings.Contains(logs.String(), scriptPrivateVar+"=") {
+		t.Errorf("expected %s not to be logged", scriptPrivateVar)
+	}
+	p := ephemeralPrivate{}
+	_ = json.Unmarshal(testPrivateKey(t, renew.Private), &p)
+	if string(p.Private) != `{"lease":"l1-renewed"}` || !renew.RenewAt.IsZero() {
+		t.Errorf("unexpected renewed private data %s %s", renew.Private, renew.RenewAt)
+	}
+
+	closed, err := s.CloseEphemeralResource(context.Background(), &tfprotov5.CloseEphemeralResourceRequest{
+		TypeName: DefaultProviderName,
+		Private:  renew.Private,
+	})
+	if err != nil || len(closed.Diagnostics) != 0 {
+		t.Fatalf("close failed %#v %#v", err, closed.Diagnostics)
+	}
+}
@@ -0,0 +1,139 @@
+package universe
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"gopkg.in/yaml.v3"
+)
+
+// builtinFunction - A provider function implemented by the provider itself, taking a single argument
+type builtinFunction struct {
+	definition function.Definition
+	call       func(input interface{}) (interface{}, error)
+}
+
+// builtinFunctions - The format conversion functions, using the same decoders as 'config'
+var builtinFunctions = map[string]builtinFunction{
+	"tomldecode": {
+		definition: decodeFunction("Decode a TOML document", "Parses a TOML document into an object."),
+		call:       tomlDecode,
+	},
+	"configdecode": {
+		definition: decodeFunction("Decode a JSON, YAML or TOML document",
+			"Parses a document the way a 'config' string is parsed, trying JSON, then YAML, then TOML."),
+		call: configDecode,
+	},
+	"tomlencode": {
+		definition: encodeFunction("Encode an object as TOML", "Encodes an object as a TOML document. Null values are left out."),
+		call:       tomlEncode,
+	},
+	"yamlencode_canonical": {
+		definition: encodeFunction("Encode a value as canonical YAML",
+			"Encodes a value as YAML with sorted keys and two space indentation, so equal values give equal documents."),
+		call: yamlEncodeCanonical,
+	},
+}
+
+func decodeFunction(summary, description string) function.Definition {
+	return function.Definition{
+		Summary:     summary,
+		Description: description,
+		Parameters:  []function.Parameter{function.StringParameter{Name: "document"}},
+		Return:      function.DynamicReturn{},
+	}
+}
+
+func encodeFunction(summary, description string) function.Definition {
+	return function.Definition{
+		Summary:     summary,
+		Description: description,
+		Parameters:  []function.Parameter{function.DynamicParameter{Name: "value", AllowNullValue: true}},
+		Return:      function.StringReturn{},
+	}
+}
+
+// decodeToJSONTypes - Decode JSON into the types produced by encoding/json, keeping numbers exact
+func decodeToJSONTypes(jsonBytes []byte) (interface{}, error) {
+	var result interface{}
+	err := unmarshalWithNumbers(jsonBytes, &result)
+	return result, err
+}
+
+func tomlDecode(input interface{}) (interface{}, error) {
+	document, _ := input.(string)
+	attributes := map[string]interface{}{}
+	if err := toml.Unmarshal([]byte(document), &attributes); err != nil {
+		return nil, err
+	}
+	jsonBytes, err := json.Marshal(attributes)
+	if err != nil {
+		return nil, err
+	}
+	return decodeToJSONTypes(jsonBytes)
+}
+
+func configDecode(input interface{}) (interface{}, error) {
+	document, _ := input.(string)
+	jsonBytes, err := decodeConfigToJSON([]byte(document))
+	if err != nil {
+		return nil, err
+	}
+	return decodeToJSONTypes(jsonBytes)
+}
+
+func tomlEncode(input interface{}) (interface{}, error) {
+	object, ok := plainNumbers(input, true).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("a TOML document must be an object, got %#v", input)
+	}
+	buf := new(bytes.Buffer)
+	if err := toml.NewEncoder(buf).Encode(object); err != nil {
+		return nil, err
+	}
+	return buf.String(), nil
+}
+
+func yamlEncodeCanonical(input interface{}) (interface{}, error) {
+	buf := new(bytes.Buffer)
+	encoder := yaml.NewEncoder(buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(plainNumbers(input, false)); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.String(), nil
+}
+
+// plainNumbers - Replace json.Number with int64 or float64, which the encoders write as numbers rather than strings.
+// TOML has no null, so null values can be dropped.
+func plainNumbers(x interface{}, dropNulls bool) interface{} {
+	switch v := x.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			if e == nil && dropNulls {
+				continue
+			}
+			result[k] = plainNumbers(e, dropNulls)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, e := range v {
+			result[i] = plainNumbers(e, dropNulls)
+		}
+		return result
+	}
+	return x
+}
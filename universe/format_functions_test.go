@@ -0,0 +1,87 @@
+package universe
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"reflect"
+	"testing"
+)
+
+func Test_tomlDecodeEncode(t *testing.T) {
+	decoded, err := tomlDecode("name = \"orders\"\nreplicas = 3\n\n[limits]\ncpu = 0.5\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"name":     "orders",
+		"replicas": json.Number("3"),
+		"limits":   map[string]interface{}{"cpu": json.Number("0.5")},
+	}
+	if !reflect.DeepEqual(decoded, expected) {
+		t.Errorf("unexpected decoded TOML %#v", decoded)
+	}
+	encoded, err := tomlEncode(expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := tomlDecode(encoded)
+	if err != nil || !reflect.DeepEqual(again, expected) {
+		t.Errorf("expected the TOML to round trip but got %s %#v", encoded, again)
+	}
+	if _, err = tomlEncode("not an object"); err == nil {
+		t.Error("expected an error for a TOML document which is not an object")
+	}
+}
+
+func Test_configDecode(t *testing.T) {
+	for _, document := range []string{`{"album": "white", "year": 1968}`, "album: white\nyear: 1968\n", "album = \"white\"\nyear = 1968\n"} {
+		decoded, err := configDecode(document)
+		if err != nil || !reflect.DeepEqual(decoded, map[string]interface{}{"album": "white", "year": json.Number("1968")}) {
+			t.Errorf("unexpected decoded %q %#v %#v", document, decoded, err)
+		}
+	}
+}
+
+func Test_yamlEncodeCanonical(t *testing.T) {
+	encoded, err := yamlEncodeCanonical(map[string]interface{}{
+		"b":    []interface{}{json.Number("1"), "two"},
+		"a":    map[string]interface{}{"y": true, "x": nil},
+		"half": json.Number("0.5"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "a:\n  x: null\n  \"y\": true\nb:\n  - 1\n  - two\nhalf: 0.5\n"
+	if encoded != expected {
+		t.Errorf("unexpected YAML %q", encoded)
+	}
+}
+
+func Test_ProviderServerFormatFunctions(t *testing.T) {
+	s := newTestServer(t)
+	document, _ := tfprotov5.NewDynamicValue(tftypes.String, tftypes.NewValue(tftypes.String, "name = \"orders\"\n"))
+	resp, err := s.CallFunction(context.Background(), &tfprotov5.CallFunctionRequest{Name: "tomldecode", Arguments: []*tfprotov5.DynamicValue{&document}})
+	if err != nil || resp.Error != nil {
+		t.Fatalf("tomldecode failed %#v %#v", err, resp.Error)
+	}
+	value, _ := resp.Result.Unmarshal(tftypes.DynamicPseudoType)
+	attrs := map[string]tftypes.Value{}
+	_ = value.As(&attrs)
+	if !attrs["name"].Equal(tftypes.NewValue(tftypes.String, "orders")) {
+		t.Errorf("unexpected result %s", value)
+	}
+
+	objectType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{"name": tftypes.String}}
+	input, _ := tfprotov5.NewDynamicValue(tftypes.DynamicPseudoType,
+		tftypes.NewValue(objectType, map[string]tftypes.Value{"name": tftypes.NewValue(tftypes.String, "orders")}))
+	resp, err = s.CallFunction(context.Background(), &tfprotov5.CallFunctionRequest{Name: "tomlencode", Arguments: []*tfprotov5.DynamicValue{&input}})
+	if err != nil || resp.Error != nil {
+		t.Fatalf("tomlencode failed %#v %#v", err, resp.Error)
+	}
+	value, _ = resp.Result.Unmarshal(tftypes.String)
+	if !value.Equal(tftypes.NewValue(tftypes.String, "name = \"orders\"\n")) {
+		t.Errorf("unexpected TOML %s", value)
+	}
+}
@@ -0,0 +1,217 @@
+package universe
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// callFunctionName - The function taking the script to run, e.g. provider::universe::call("python3", "hash.py", {...})
+const callFunctionName = "call"
+
+// functionCommand - How to run a function, as for resources the script is run directly without an executor
+type functionCommand struct {
+	Executor string `json:"executor"`
+	Script   string `json:"script"`
+}
+
+// functionNamePattern - The names Terraform accepts for provider functions
+var functionNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+var (
+	functionResults = map[string][]byte{}
+	functionMutex   sync.Mutex
+)
+
+// getFunctionsFromEnvironment
+// Assuming the environment has a variable TERRAFORM_<PROVIDERNAME>_FUNCTIONS e.g. TERRAFORM_UNIVERSE_FUNCTIONS
+// containing a JSON object of function names and the scripts which run them,
+// e.g. {"slug": {"executor": "python3", "script": "slug.py"}}. Return the functions, or none
+func getFunctionsFromEnvironment(providerName string) map[string]functionCommand {
+	functionsVarName := "TERRAFORM_" + strings.ToUpper(providerName) + "_FUNCTIONS"
+	value, ok := os.LookupEnv(functionsVarName)
+	if !ok {
+		return nil
+	}
+	functions := map[string]functionCommand{}
+	if err := json.Unmarshal([]byte(value), &functions); err != nil {
+		log.Printf("getFunctionsFromEnvironment() expected a JSON object of executors and scripts in %s: %#v", functionsVarName, err)
+		return nil
+	}
+	for name := range functions {
+		if _, builtin := builtinFunctions[name]; builtin || name == callFunctionName || !functionNamePattern.MatchString(name) {
+			log.Printf("getFunctionsFromEnvironment() ignoring the function '%s' in %s", name, functionsVarName)
+			delete(functions, name)
+		}
+	}
+	return functions
+}
+
+// functionDefinitions - 'call', the format functions and the functions declared in the environment
+func functionDefinitions() map[string]function.Definition {
+	functions := map[string]function.Definition{
+		callFunctionName: {
+			Summary:     "Run a script with the 'function' event",
+			Description: "Runs the script with the executor, the 'function' event and the input as JSON on stdin, returning the JSON printed by the script. An empty executor runs the script directly.",
+			Parameters: []function.Parameter{
+				function.StringParameter{Name: "executor", Description: "The program running the script, e.g. 'python3', or empty to run the script directly."},
+				function.StringParameter{Name: "script", Description: "The path of the script."},
+				function.DynamicParameter{Name: "input", AllowNullValue: true, Description: "The input passed to the script on stdin."},
+			},
+			Return: function.DynamicReturn{},
+		},
+	}
+	for name, builtin := range builtinFunctions {
+		functions[name] = builtin.definition
+	}
+	for name, command := range getFunctionsFromEnvironment(getProviderNameFromBinaryOrEnvironment()) {
+		functions[name] = function.Definition{
+			Summary:     fmt.Sprintf("Run '%s' with the 'function' event", command.Script),
+			Description: "Runs the script with the 'function' event and the input as JSON on stdin, returning the JSON printed by the script.",
+			Parameters: []function.Parameter{
+				function.DynamicParameter{Name: "input", AllowNullValue: true, Description: "The input passed to the script on stdin."},
+			},
+			Return: function.DynamicReturn{},
+		}
+	}
+	return functions
+}
+
+// functionDefaults - The effective defaults to run a function. Without an executor the script is run directly.
+func functionDefaults(name string, command functionCommand) (map[string]interface{}, error) {
+	if command.Script == "" {
+		return nil, fmt.Errorf("no script to run for the function '%s'", name)
+	}
+	effectiveDefaults := map[string]interface{}{"function": name, "script": command.Script}
+	if command.Executor != "" {
+		effectiveDefaults["executor"] = command.Executor
+	}
+	return effectiveDefaults, nil
+}
+
+// callScriptFunction - Run the 'function' event with the input on stdin. Functions must give the same result
+// for the same input, so the result is remembered until the script changes.
+func callScriptFunction(name string, command functionCommand, input interface{}) (interface{}, error) {
+	effectiveDefaults, err := functionDefaults(name, command)
+	if err != nil {
+		return nil, err
+	}
+	stdin, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.Sum256([]byte(scriptHash(effectiveDefaults) + "\x00" + name + "\x00" + string(stdin)))
+	key := hex.EncodeToString(h[:])
+
+	functionMutex.Lock()
+	rawResponse, ok := functionResults[key]
+	functionMutex.Unlock()
+	if !ok {
+		if rawResponse, err = runScript("function", "", effectiveDefaults, stdin); err != nil {
+			return nil, err
+		}
+		functionMutex.Lock()
+		functionResults[key] = rawResponse
+		functionMutex.Unlock()
+	}
+	result, err := jsonSafeUnmarshal(rawResponse, nil)
+	if err != nil {
+		return nil, fmt.Errorf("expecting JSON from the function event, got '%s': %w", string(rawResponse), err)
+	}
+	return result, nil
+}
+
+// providerFunction - A function of the provider, see functionDefinitions
+type providerFunction struct {
+	name       string
+	definition function.Definition
+}
+
+// functions - The functions of the provider, sorted by name
+func functions() []func() function.Function {
+	definitions := functionDefinitions()
+	names := make([]string, 0, len(definitions))
+	for name := range definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := make([]func() function.Function, 0, len(names))
+	for _, name := range names {
+		f := &providerFunction{name: name, definition: definitions[name]}
+		result = append(result, func() function.Function { return f })
+	}
+	return result
+}
+
+func (f *providerFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = f.name
+}
+
+func (f *providerFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = f.definition
+}
+
+// Run - Decode the arguments, run the function and encode its result
+func (f *providerFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	values := make([]attr.Value, len(f.definition.Parameters))
+	targets := make([]any, len(values))
+	for i := range values {
+		targets[i] = &values[i]
+	}
+	if resp.Error = req.Arguments.Get(ctx, targets...); resp.Error != nil {
+		return
+	}
+	arguments := make([]interface{}, len(values))
+	for i, value := range values {
+		v, err := value.ToTerraformValue(ctx)
+		if err == nil {
+			arguments[i], err = valueToJSON(v)
+		}
+		if err != nil {
+			resp.Error = function.NewArgumentFuncError(int64(i), err.Error())
+			return
+		}
+	}
+
+	var output interface{}
+	var err error
+	if builtin, ok := builtinFunctions[f.name]; ok {
+		output, err = builtin.call(arguments[0])
+	} else if f.name == callFunctionName {
+		command := functionCommand{}
+		command.Executor, _ = arguments[0].(string)
+		if command.Script, _ = arguments[1].(string); command.Script == "" {
+			resp.Error = function.NewArgumentFuncError(1, "expected the script to run")
+			return
+		}
+		output, err = callScriptFunction(f.name, command, arguments[2])
+	} else {
+		command := getFunctionsFromEnvironment(getProviderNameFromBinaryOrEnvironment())[f.name]
+		output, err = callScriptFunction(f.name, command, arguments[0])
+	}
+	if err != nil {
+		resp.Error = function.NewFuncError(err.Error())
+		return
+	}
+	resultValue, err := jsonToValue(output)
+	if err != nil {
+		resp.Error = function.NewFuncError(err.Error())
+		return
+	}
+	result, err := f.definition.Return.GetType().ValueFromTerraform(ctx, resultValue)
+	if err != nil {
+		resp.Error = function.NewFuncError(err.Error())
+		return
+	}
+	resp.Result = function.NewResultData(result)
+}
@@ -0,0 +1,112 @@
+package universe
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_getFunctionsFromEnvironment(t *testing.T) {
+	t.Setenv("TERRAFORM_UNIVERSE_FUNCTIONS", `{"slug": {"executor": "python3", "script": "slug.py"}, "call": {"script": "x.py"}, "bad-name": {"script": "y.py"}}`)
+	functions := getFunctionsFromEnvironment("universe")
+	if !reflect.DeepEqual(functions, map[string]functionCommand{"slug": {Executor: "python3", Script: "slug.py"}}) {
+		t.Errorf("unexpected functions %#v", functions)
+	}
+	t.Setenv("TERRAFORM_UNIVERSE_FUNCTIONS", `not json`)
+	if functions = getFunctionsFromEnvironment("universe"); len(functions) != 0 {
+		t.Errorf("expected no functions %#v", functions)
+	}
+}
+
+func Test_functionDefaults(t *testing.T) {
+	d, err := functionDefaults("call", functionCommand{Executor: "python3", Script: "my scripts/hash.py"})
+	if err != nil || d["executor"] != "python3" || d["script"] != "my scripts/hash.py" || d["function"] != "call" {
+		t.Errorf("unexpected defaults %#v %#v", d, err)
+	}
+	d, err = functionDefaults("slug", functionCommand{Script: "slug.sh"})
+	if _, ok := d["executor"]; err != nil || ok || d["script"] != "slug.sh" {
+		t.Errorf("expected the script to run directly %#v %#v", d, err)
+	}
+	if _, err = functionDefaults("slug", functionCommand{Executor: "python3"}); err == nil {
+		t.Error("expected an error without a script")
+	}
+}
+
+func Test_callScriptFunctionMemoised(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "calls")
+	input := map[string]interface{}{"name": "white", "counter": counter}
+	for i := 0; i < 2; i++ {
+		result, err := callScriptFunction("call", functionCommand{Executor: "python3", Script: "resource_universe_test.py"}, input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(result, map[string]interface{}{"upper": "WHITE"}) {
+			t.Errorf("unexpected result %#v", result)
+		}
+	}
+	calls, _ := os.ReadFile(counter)
+	if string(calls) != "call\n" {
+		t.Errorf("expected the script to be run once but got %q", calls)
+	}
+}
+
+func Test_ProviderServerCallFunction(t *testing.T) {
+	t.Setenv("TERRAFORM_UNIVERSE_FUNCTIONS", `{"shout": {"executor": "python3", "script": "resource_universe_test.py"}}`)
+	s := newTestServer(t)
+	functions, _ := s.GetFunctions(context.Background(), &tfprotov5.GetFunctionsRequest{})
+	if _, ok := functions.Functions["shout"]; !ok || functions.Functions[callFunctionName] == nil {
+		t.Errorf("unexpected functions %#v", functions.Functions)
+	}
+	objectType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{"name": tftypes.String}}
+	input, _ := tfprotov5.NewDynamicValue(tftypes.DynamicPseudoType,
+		tftypes.NewValue(objectType, map[string]tftypes.Value{"name": tftypes.NewValue(tftypes.String, "abbey road")}))
+	executor, _ := tfprotov5.NewDynamicValue(tftypes.String, tftypes.NewValue(tftypes.String, "python3"))
+	script, _ := tfprotov5.NewDynamicValue(tftypes.String, tftypes.NewValue(tftypes.String, "resource_universe_test.py"))
+	for name, arguments := range map[string][]*tfprotov5.DynamicValue{
+		callFunctionName: {&executor, &script, &input},
+		"shout":          {&input},
+	} {
+		resp, err := s.CallFunction(context.Background(), &tfprotov5.CallFunctionRequest{Name: name, Arguments: arguments})
+		if err != nil || resp.Error != nil {
+			t.Fatalf("%s failed %#v %#v", name, err, resp.Error)
+		}
+		value, err := resp.Result.Unmarshal(tftypes.DynamicPseudoType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := map[string]tftypes.Value{}
+		_ = value.As(&result)
+		if !result["upper"].Equal(tftypes.NewValue(tftypes.String, "ABBEY ROAD")) {
+			t.Errorf("unexpected result of %s %s", name, value)
+		}
+	}
+	resp, _ := s.CallFunction(context.Background(), &tfprotov5.CallFunctionRequest{Name: "missing"})
+	if resp.Error == nil || !strings.Contains(resp.Error.Text, "missing") {
+		t.Errorf("expected an error for an unknown function %#v", resp.Error)
+	}
+}
+
+func Test_callScriptFunctionPathWithSpace(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "my scripts")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	source, err := os.ReadFile("resource_universe_test.py")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, "shout.py"), source, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pwd, _ := os.Getwd()
+	script, _ := filepath.Rel(pwd, filepath.Join(dir, "shout.py")) // Scripts are relative to the working directory
+	result, err := callScriptFunction("call", functionCommand{Executor: "python3", Script: script}, map[string]interface{}{"name": "white"})
+	if err != nil || !reflect.DeepEqual(result, map[string]interface{}{"upper": "WHITE"}) {
+		t.Errorf("unexpected result %#v %#v", result, err)
+	}
+}
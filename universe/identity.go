@@ -0,0 +1,139 @@
+package universe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/identityschema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// defaultIdentityKeys - Without configuration the identity is the id of the resource
+var defaultIdentityKeys = []string{"id"}
+
+// getIdentityKeysFromEnvironment
+// Assuming the environment has a variable TERRAFORM_<RESOURCETYPE>_IDENTITY e.g. TERRAFORM_UNIVERSE_JSON_FILE_IDENTITY
+// containing a whitespace-separated list of keys in the result which identify the resource.
+// Return the keys, or just "id"
+func getIdentityKeysFromEnvironment(resourceName string) []string {
+	identityVarName := "TERRAFORM_" + strings.ToUpper(resourceName) + "_IDENTITY"
+	keys := strings.Fields(os.Getenv(identityVarName))
+	if len(keys) == 0 {
+		return defaultIdentityKeys
+	}
+	return keys
+}
+
+// IdentitySchema - A string attribute for each identity key, all needed to import
+func (r *universeResource) IdentitySchema(_ context.Context, _ resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	attributes := make(map[string]identityschema.Attribute, len(r.identityKeys))
+	for _, key := range r.identityKeys {
+		attributes[key] = identityschema.StringAttribute{
+			Description:       fmt.Sprintf("The '%s' of the resource.", key),
+			RequiredForImport: true,
+		}
+	}
+	resp.IdentitySchema = identityschema.Schema{Attributes: attributes}
+}
+
+// identityImportRequest - Make the 'import' event request from the identity given in an import block
+func identityImportRequest(d *resourceModel, identity *tfsdk.ResourceIdentity, keys []string) (importRequest, error) {
+	request := importRequest{Keys: map[string]string{}}
+	if identity == nil || identity.Raw.IsNull() {
+		return request, fmt.Errorf("expected an import ID or identity")
+	}
+	attrs := map[string]tftypes.Value{}
+	if err := identity.Raw.As(&attrs); err != nil {
+		return request, err
+	}
+	for _, key := range keys {
+		var value *string
+		if err := attrs[key].As(&value); err != nil {
+			return request, fmt.Errorf("%s: %w", key, err)
+		}
+		if value != nil {
+			request.Keys[key] = *value
+		}
+	}
+	if len(keys) == 1 && keys[0] == "id" {
+		request.ID = request.Keys["id"]
+		d.ID = types.StringValue(request.ID)
+	}
+	log.Printf("identityImportRequest() importing %#v", request)
+	return request, nil
+}
+
+// identityType - The object type of the identity, a string for each key
+func identityType(keys []string) tftypes.Object {
+	attributeTypes := make(map[string]tftypes.Type, len(keys))
+	for _, key := range keys {
+		attributeTypes[key] = tftypes.String
+	}
+	return tftypes.Object{AttributeTypes: attributeTypes}
+}
+
+// identityObject - The identity of an object from its id and the keys in its result
+func identityObject(keys []string, id string, result map[string]interface{}) (tftypes.Value, error) {
+	values := make(map[string]tftypes.Value, len(keys))
+	for _, key := range keys {
+		value, err := identityValue(key, id, result)
+		if err != nil {
+			return tftypes.Value{}, err
+		}
+		values[key] = tftypes.NewValue(tftypes.String, value)
+	}
+	return tftypes.NewValue(identityType(keys), values), nil
+}
+
+// setIdentity - Set the identity from the id and the keys in the result of the resource. A key missing from
+// the result is left empty and returned in the errors, as the resource itself was applied.
+func setIdentity(identity *tfsdk.ResourceIdentity, d *resourceModel, keys []string) ([]error, error) {
+	if identity == nil || d.ID.IsNull() {
+		return nil, nil // Gone
+	}
+	result := map[string]interface{}{}
+	if !d.Result.IsNull() && !d.Result.IsUnknown() {
+		x, err := dynamicToJSON(d.Result)
+		if err != nil {
+			return nil, err
+		}
+		result, _ = x.(map[string]interface{})
+	}
+	var missing []error
+	values := make(map[string]tftypes.Value, len(keys))
+	for _, key := range keys {
+		value, err := identityValue(key, d.ID.ValueString(), result)
+		if err != nil {
+			missing = append(missing, err)
+		}
+		values[key] = tftypes.NewValue(tftypes.String, value)
+	}
+	identity.Raw = tftypes.NewValue(identityType(keys), values)
+	return missing, nil
+}
+
+// identityValue - The value of an identity key as a string. 'id' is the id of the resource,
+// other keys come from the result with numbers and booleans converted to strings.
+func identityValue(key string, id string, result map[string]interface{}) (string, error) {
+	if key == "id" {
+		return id, nil
+	}
+	v := result[key]
+	switch value := v.(type) {
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case float64, bool:
+		b, _ := json.Marshal(value)
+		return string(b), nil
+	}
+	return "", fmt.Errorf("expected a string, number or boolean in identity key '%s' of the result but got %#v", key, v)
+}
@@ -0,0 +1,139 @@
+package universe
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_getIdentityKeysFromEnvironment(t *testing.T) {
+	if keys := getIdentityKeysFromEnvironment("universe_json_file"); !reflect.DeepEqual(keys, []string{"id"}) {
+		t.Errorf("unexpected default keys %#v", keys)
+	}
+	t.Setenv("TERRAFORM_UNIVERSE_JSON_FILE_IDENTITY", " project  name ")
+	if keys := getIdentityKeysFromEnvironment("universe_json_file"); !reflect.DeepEqual(keys, []string{"project", "name"}) {
+		t.Errorf("unexpected keys %#v", keys)
+	}
+}
+
+func Test_identityValue(t *testing.T) {
+	result := map[string]interface{}{"id": "other", "n": 12.0, "s": "x", "o": map[string]interface{}{}}
+	for key, expected := range map[string]string{"id": "42", "n": "12", "s": "x"} {
+		if v, err := identityValue(key, "42", result); err != nil || v != expected {
+			t.Errorf("expected %s for %s but got %s %#v", expected, key, v, err)
+		}
+	}
+	for _, key := range []string{"o", "missing"} {
+		if _, err := identityValue(key, "42", result); err == nil {
+			t.Errorf("expected an error for %s", key)
+		}
+	}
+}
+
+var testIdentityType = tftypes.Object{AttributeTypes: map[string]tftypes.Type{"id": tftypes.String}}
+
+func testIdentityID(t *testing.T, identity *tfprotov5.ResourceIdentityData) string {
+	if identity == nil || identity.IdentityData == nil {
+		t.Fatal("missing identity")
+	}
+	value, err := identity.IdentityData.Unmarshal(testIdentityType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attrs := map[string]tftypes.Value{}
+	_ = value.As(&attrs)
+	var id string
+	_ = attrs["id"].As(&id)
+	return id
+}
+
+func Test_ProviderServerIdentity(t *testing.T) {
+	s := newTestServer(t)
+	testConfigureServer(t, s)
+	identitySchemas, err := s.GetResourceIdentitySchemas(context.Background(), &tfprotov5.GetResourceIdentitySchemasRequest{})
+	if err != nil || identitySchemas.IdentitySchemas[DefaultProviderName] == nil {
+		t.Fatalf("expected an identity schema %#v", err)
+	}
+
+	config := testServerValue(t, s, map[string]tftypes.Value{"config": testObjectConfig()})
+	priorNull, _ := tfprotov5.NewDynamicValue(s.resourceType, tftypes.NewValue(s.resourceType, nil))
+	plan, err := s.PlanResourceChange(context.Background(), &tfprotov5.PlanResourceChangeRequest{
+		TypeName:         DefaultProviderName,
+		PriorState:       &priorNull,
+		ProposedNewState: config,
+		Config:           config,
+	})
+	if err != nil || len(plan.Diagnostics) != 0 {
+		t.Fatalf("plan failed %#v %#v", err, plan.Diagnostics)
+	}
+	apply, err := s.ApplyResourceChange(context.Background(), &tfprotov5.ApplyResourceChangeRequest{
+		TypeName:        DefaultProviderName,
+		PriorState:      &priorNull,
+		PlannedState:    plan.PlannedState,
+		PlannedIdentity: plan.PlannedIdentity,
+		Config:          config,
+	})
+	if err != nil || len(apply.Diagnostics) != 0 {
+		t.Fatalf("apply failed %#v %#v", err, apply.Diagnostics)
+	}
+	if id := testIdentityID(t, apply.NewIdentity); id != "42" {
+		t.Errorf("unexpected identity %s", id)
+	}
+
+	// Import by identity
+	identity, _ := tfprotov5.NewDynamicValue(testIdentityType, tftypes.NewValue(testIdentityType, map[string]tftypes.Value{
+		"id": tftypes.NewValue(tftypes.String, "42"),
+	}))
+	imported, err := s.ImportResourceState(context.Background(), &tfprotov5.ImportResourceStateRequest{
+		TypeName: DefaultProviderName,
+		Identity: &tfprotov5.ResourceIdentityData{IdentityData: &identity},
+	})
+	if err != nil || len(imported.Diagnostics) != 0 || len(imported.ImportedResources) != 1 {
+		t.Fatalf("import failed %#v %#v", err, imported.Diagnostics)
+	}
+	if id := testIdentityID(t, imported.ImportedResources[0].Identity); id != "42" {
+		t.Errorf("unexpected identity %s", id)
+	}
+}
+
+func Test_ProviderServerIdentityMissingKey(t *testing.T) {
+	t.Setenv("TERRAFORM_UNIVERSE_IDENTITY", "id missing")
+	s := newTestServer(t)
+	testConfigureServer(t, s)
+	config := testServerValue(t, s, map[string]tftypes.Value{"config": testObjectConfig()})
+	priorNull, _ := tfprotov5.NewDynamicValue(s.resourceType, tftypes.NewValue(s.resourceType, nil))
+	plan, err := s.PlanResourceChange(context.Background(), &tfprotov5.PlanResourceChangeRequest{
+		TypeName:         DefaultProviderName,
+		PriorState:       &priorNull,
+		ProposedNewState: config,
+		Config:           config,
+	})
+	if err != nil || len(plan.Diagnostics) != 0 {
+		t.Fatalf("plan failed %#v %#v", err, plan.Diagnostics)
+	}
+	apply, err := s.ApplyResourceChange(context.Background(), &tfprotov5.ApplyResourceChangeRequest{
+		TypeName:     DefaultProviderName,
+		PriorState:   &priorNull,
+		PlannedState: plan.PlannedState,
+		Config:       config,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The resource was created, so a key missing from the result is a warning and the apply succeeds
+	if len(apply.Diagnostics) != 1 || apply.Diagnostics[0].Severity != tfprotov5.DiagnosticSeverityWarning {
+		t.Fatalf("expected a warning %#v", apply.Diagnostics)
+	}
+	if !strings.Contains(apply.Diagnostics[0].Detail, "'missing'") {
+		t.Errorf("expected the warning to name the key %s", apply.Diagnostics[0].Detail)
+	}
+	value, _ := apply.NewState.Unmarshal(s.resourceType)
+	attrs := map[string]tftypes.Value{}
+	_ = value.As(&attrs)
+	if !attrs["id"].Equal(tftypes.NewValue(tftypes.String, "42")) {
+		t.Errorf("expected the created resource in the state but got %s", value)
+	}
+}
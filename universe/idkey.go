@@ -0,0 +1,91 @@
+package universe
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// defaultIDSeparator - Joins the values of several keys in 'id_key' into the id
+const defaultIDSeparator = "/"
+
+// idKeys - Split 'id_key' into its keys. Several keys are separated by commas, each is a top level key
+// or a JSON pointer such as '/metadata/uid'.
+func idKeys(idKey string) []string {
+	var keys []string
+	for _, key := range strings.Split(idKey, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// idSeparator - The 'id_separator' setting or the default
+func idSeparator(effectiveDefaults map[string]interface{}) string {
+	if sep, ok := effectiveDefaults["id_separator"].(string); ok && sep != "" {
+		return sep
+	}
+	return defaultIDSeparator
+}
+
+// idFromResponse - Make the id from the values of the 'id_key' keys in the response
+func idFromResponse(response map[string]interface{}, effectiveDefaults map[string]interface{}) (string, error) {
+	idKey, _ := effectiveDefaults["id_key"].(string)
+	keys := idKeys(idKey)
+	if len(keys) == 0 {
+		return "", fmt.Errorf("no keys in id_key '%s'", idKey)
+	}
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		raw, ok := jsonPointerGet(response, key)
+		if !ok {
+			return "", fmt.Errorf("missing id attribute '%s' in response", key)
+		}
+		part, err := idString(key, raw)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, idSeparator(effectiveDefaults)), nil
+}
+
+// idString - Ids may be strings or numbers, numbers are written without exponents
+func idString(key string, raw interface{}) (string, error) {
+	switch v := raw.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("expected string or number in id attribute '%s' in response but got: %#v", key, raw)
+}
+
+// idEnvironment - Split the id back into the values of the 'id_key' keys, as environment variables for the script.
+// The variable for a JSON pointer is named by its last token, e.g. 'uid' for '/metadata/uid'.
+func idEnvironment(id string, effectiveDefaults map[string]interface{}) []string {
+	idKey, _ := effectiveDefaults["id_key"].(string)
+	keys := idKeys(idKey)
+	if len(keys) == 0 {
+		return nil
+	}
+	parts := []string{id}
+	if len(keys) > 1 {
+		parts = strings.SplitN(id, idSeparator(effectiveDefaults), len(keys))
+		if len(parts) != len(keys) {
+			log.Printf("idEnvironment() cannot split id '%s' into %s", id, idKey)
+			parts = []string{id}
+		}
+	}
+	environ := make([]string, 0, len(parts))
+	for i, part := range parts {
+		tokens := splitJSONPointer(keys[i])
+		environ = append(environ, fmt.Sprintf("%s=%s", tokens[len(tokens)-1], part))
+	}
+	return environ
+}
@@ -0,0 +1,48 @@
+package universe
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_idFromResponse(t *testing.T) {
+	var response map[string]interface{}
+	err := unmarshalWithNumbers([]byte(`{"project": "acme", "metadata": {"uid": 9007199254740993}, "name": "x"}`), &response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		defaults map[string]interface{}
+		expected string
+	}{
+		{map[string]interface{}{"id_key": "name"}, "x"},
+		{map[string]interface{}{"id_key": "/metadata/uid"}, "9007199254740993"},
+		{map[string]interface{}{"id_key": "project, name"}, "acme/x"},
+		{map[string]interface{}{"id_key": "project,/metadata/uid", "id_separator": ":"}, "acme:9007199254740993"},
+	}
+	for _, c := range cases {
+		id, err := idFromResponse(response, c.defaults)
+		if err != nil || id != c.expected {
+			t.Errorf("expected %s for %#v but got %s %#v", c.expected, c.defaults, id, err)
+		}
+	}
+	for _, idKey := range []string{"missing", "metadata", ""} {
+		if _, err := idFromResponse(response, map[string]interface{}{"id_key": idKey}); err == nil {
+			t.Errorf("expected an error for %s", idKey)
+		}
+	}
+	if id, _ := idFromResponse(map[string]interface{}{"id": 12.0}, map[string]interface{}{"id_key": "id"}); id != "12" {
+		t.Errorf("unexpected id %s", id)
+	}
+}
+
+func Test_idEnvironment(t *testing.T) {
+	env := idEnvironment("acme/x/y", map[string]interface{}{"id_key": "project,/metadata/name"})
+	if !reflect.DeepEqual(env, []string{"project=acme", "name=x/y"}) {
+		t.Errorf("unexpected environment %#v", env)
+	}
+	env = idEnvironment("42", map[string]interface{}{"id_key": "id"})
+	if !reflect.DeepEqual(env, []string{"id=42"}) {
+		t.Errorf("unexpected environment %#v", env)
+	}
+}
@@ -0,0 +1,97 @@
+package universe
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// importRequest - The JSON passed on stdin to the script for the 'import' event
+type importRequest struct {
+	// ID - the ID given to 'terraform import'
+	ID string `json:"id"`
+	// Type - the part before ':' of a composite ID such as 'user:name=bob,org=acme'
+	Type string `json:"type,omitempty"`
+	// Keys - the key=value pairs of a composite ID
+	Keys map[string]string `json:"keys,omitempty"`
+}
+
+// parseImportID - Split a composite import ID 'type:key=value,key=value'. Any other ID is returned as it is.
+func parseImportID(id string) importRequest {
+	request := importRequest{ID: id}
+	i := strings.Index(id, ":")
+	if i < 0 {
+		return request
+	}
+	keys := map[string]string{}
+	for _, pair := range strings.Split(id[i+1:], ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return request
+		}
+		keys[kv[0]] = kv[1]
+	}
+	request.Type = id[:i]
+	request.Keys = keys
+	return request
+}
+
+// callImport - Run the 'import' event and put the response in 'config', 'result' and 'outputs'.
+// Scripts which do not handle the event get the ID alone, as before.
+func callImport(d *resourceModel, providerConfig interface{}, request importRequest) error {
+	effectiveDefaults, id, err := extractEssentialFields("import", d.settings(), providerConfig)
+	if err != nil {
+		// Terraform does not pass the resource block to import
+		return fmt.Errorf("%w: import only has the provider configuration, set 'executor', 'script' and 'id_key' in the provider block to import", err)
+	}
+	capabilities := getCapabilities(effectiveDefaults)
+	if !capabilities.supportsEvent("import") {
+		log.Printf("callImport() the script has no 'import' event, importing the id '%s' alone", id)
+		return nil
+	}
+	stdin, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	rawResponse, err := runScript("import", id, effectiveDefaults, stdin)
+	if err != nil {
+		if capabilities == nil {
+			log.Printf("callImport() importing the id '%s' alone, the 'import' event failed: %#v", id, err)
+			return nil
+		}
+		return err
+	}
+	response, err := jsonSafeUnmarshal(rawResponse, nil)
+	if err != nil {
+		return fmt.Errorf("expecting JSON from the import event, got '%s': %w", string(rawResponse), err)
+	}
+	responseMap, ok := response.(map[string]interface{})
+	if !ok {
+		if capabilities == nil {
+			log.Printf("callImport() importing the id '%s' alone, the 'import' event returned: %s", id, string(rawResponse))
+			return nil
+		}
+		return fmt.Errorf("expecting an object from the import event, got '%s'", string(rawResponse))
+	}
+
+	if newID, err := idFromResponse(responseMap, effectiveDefaults); err == nil && newID != "" {
+		d.ID = types.StringValue(newID)
+	}
+	if err = setResult(d, responseMap); err != nil {
+		return err
+	}
+	setConfigVersion(d, effectiveDefaults)
+	// The config holds what the user would write, computed '@' fields are left in 'result'
+	config := map[string]interface{}{}
+	for k, v := range responseMap {
+		if !strings.HasPrefix(k, "@") {
+			config[k] = v
+		}
+	}
+	log.Printf("callImport() setting config to: %#v", config)
+	d.Config, err = jsonToDynamic(config)
+	return err
+}
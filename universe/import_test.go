@@ -0,0 +1,60 @@
+package universe
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_parseImportID(t *testing.T) {
+	r := parseImportID("user:name=bob,org=acme")
+	if r.Type != "user" || !reflect.DeepEqual(r.Keys, map[string]string{"name": "bob", "org": "acme"}) {
+		t.Errorf("unexpected composite id %#v", r)
+	}
+	for _, id := range []string{"42", "/tmp/a:b", "x:a=1,b"} {
+		r = parseImportID(id)
+		if r.ID != id || r.Type != "" || r.Keys != nil {
+			t.Errorf("expected %s as it is but got %#v", id, r)
+		}
+	}
+}
+
+func Test_callImport(t *testing.T) {
+	d := nullResourceModel()
+	d.ID = types.StringValue("album:id=42")
+	err := callImport(&d, map[string]interface{}{"id_key": "id", "executor": "python3", "script": "resource_universe_test.py"}, parseImportID("album:id=42"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.ID.ValueString() != "42" {
+		t.Errorf("unexpected id %s", d.ID)
+	}
+	n1, _ := normalizeJSONString(testJSON(t, d.Config))
+	n2, _ := normalizeJSONString(`{"id": "42", "album": "white"}`)
+	if n1 != n2 {
+		t.Errorf("unexpected config %s", n1)
+	}
+	if d.Outputs.Elements()["@created"] != types.StringValue("26/10/2020 18:55:51") {
+		t.Errorf("unexpected outputs %s", d.Outputs)
+	}
+
+	// A script without the import event keeps the id alone
+	d = nullResourceModel()
+	d.ID = types.StringValue("x")
+	err = callImport(&d, testCapabilitiesConfig, parseImportID("x"))
+	if err != nil || d.ID.ValueString() != "x" {
+		t.Fatal(err)
+	}
+	if !d.Config.IsNull() {
+		t.Fail()
+	}
+
+	// Without the script in the provider configuration the error says where it is needed
+	d = nullResourceModel()
+	d.ID = types.StringValue("x")
+	err = callImport(&d, map[string]interface{}{"id_key": "id"}, parseImportID("x"))
+	if err == nil || !strings.Contains(err.Error(), "provider block") {
+		t.Errorf("expected an error naming the provider block but got %#v", err)
+	}
+}
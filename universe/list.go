@@ -0,0 +1,82 @@
+package universe
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// listEntry - An existing object returned by the script for the 'list' event
+type listEntry struct {
+	ID     string
+	Config map[string]interface{}
+}
+
+// callList - Run the 'list' event with the filter on stdin. The script returns a JSON array whose
+// elements are either {"id": ..., "config": {...}}, or the config itself holding the id in the 'id_key' fields.
+func callList(effectiveDefaults map[string]interface{}, filter map[string]interface{}) ([]listEntry, error) {
+	if filter == nil {
+		filter = map[string]interface{}{}
+	}
+	stdin, err := json.Marshal(filter)
+	if err != nil {
+		return nil, err
+	}
+	rawResponse, err := runScript("list", "", effectiveDefaults, stdin)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("callList() response of %d bytes", len(rawResponse))
+	var response []map[string]interface{}
+	err = unmarshalWithNumbers(rawResponse, &response)
+	if err != nil {
+		return nil, fmt.Errorf("expecting an array of objects from the list event, got '%s': %w", string(rawResponse), err)
+	}
+	entries := make([]listEntry, 0, len(response))
+	for _, element := range response {
+		entry, err := makeListEntry(element, effectiveDefaults)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// makeListEntry - Find the id and the config in an element of the 'list' response. Computed '@' and sensitive '!'
+// fields are left out of the config.
+func makeListEntry(element map[string]interface{}, effectiveDefaults map[string]interface{}) (listEntry, error) {
+	entry := listEntry{}
+	config, wrapped := element["config"].(map[string]interface{})
+	if wrapped {
+		for k := range element {
+			if k != "id" && k != "config" {
+				wrapped = false
+			}
+		}
+	}
+	var err error
+	if wrapped {
+		if element["id"] == nil {
+			return entry, fmt.Errorf("missing id in list element: %#v", element)
+		}
+		entry.ID, err = idString("id", element["id"])
+	} else {
+		config = element
+		entry.ID, err = idFromResponse(element, effectiveDefaults)
+	}
+	if err != nil {
+		return entry, fmt.Errorf("list element %#v: %w", element, err)
+	}
+	if entry.ID == "" {
+		return entry, fmt.Errorf("missing id in list element: %#v", element)
+	}
+	entry.Config = map[string]interface{}{}
+	for k, v := range config {
+		if !strings.HasPrefix(k, "@") && !strings.HasPrefix(k, sensitivePrefix) {
+			entry.Config[k] = v
+		}
+	}
+	return entry, nil
+}
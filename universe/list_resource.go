@@ -0,0 +1,148 @@
+package universe
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	"github.com/hashicorp/terraform-plugin-framework/list/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"slices"
+)
+
+// listResource - The list block of a resource type. The script, executor and id_key default to the provider's,
+// 'filter' is passed to the script's 'list' event.
+type listResource struct {
+	typeName string
+	// identityKeys - the keys in the result which identify an object, see getIdentityKeysFromEnvironment
+	identityKeys []string
+	// providerConfig - the provider configuration, once the provider is configured
+	providerConfig interface{}
+}
+
+var _ list.ListResourceWithConfigure = &listResource{}
+
+func newListResource(typeName string) list.ListResource {
+	return &listResource{typeName: typeName, identityKeys: getIdentityKeysFromEnvironment(typeName)}
+}
+
+func (l *listResource) Metadata(_ context.Context, _ resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = l.typeName
+}
+
+func (l *listResource) Configure(_ context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
+	if req.ProviderData != nil {
+		l.providerConfig = req.ProviderData
+	}
+}
+
+func (l *listResource) ListResourceConfigSchema(_ context.Context, _ list.ListResourceSchemaRequest, resp *list.ListResourceSchemaResponse) {
+	resp.Schema = listResourceSchema()
+}
+
+// listResourceSchema - The schema of the list block of every resource type
+func listResourceSchema() schema.Schema {
+	return schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"executor": schema.StringAttribute{Optional: true, Description: "The name of the program to run. e.g. python"},
+			"script":   schema.StringAttribute{Optional: true, Description: "The path to the script passed as the first argument to 'executor'."},
+			"id_key":   schema.StringAttribute{Optional: true, Description: "The name of the key which holds the unique identifier of the resource. e.g. 'id'"},
+			"filter": schema.DynamicAttribute{
+				Optional:    true,
+				Description: "An object passed to the script on stdin to select the objects to list.",
+			},
+		},
+	}
+}
+
+// listConfig - Decode the config of a list block
+func listConfig(value tftypes.Value) (attributeMap, error) {
+	config := attributeMap{}
+	if value.IsNull() {
+		return config, nil
+	}
+	attrs := map[string]tftypes.Value{}
+	if err := value.As(&attrs); err != nil {
+		return nil, err
+	}
+	var err error
+	for name, v := range attrs {
+		if !v.IsKnown() {
+			return nil, fmt.Errorf("'%s' is not known until apply", name)
+		}
+		if config[name], err = valueToJSON(v); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	if filter, ok := config.GetOk("filter"); ok {
+		if _, ok := filter.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("expected an object in 'filter' but got %#v", filter)
+		}
+	}
+	return config, nil
+}
+
+// List - Run the script's 'list' event and stream a result for each object
+func (l *listResource) List(ctx context.Context, req list.ListRequest, stream *list.ListResultsStream) {
+	failed := func(summary string, err error) {
+		var diags diag.Diagnostics
+		diags.AddError(summary, err.Error())
+		stream.Results = list.ListResultsStreamDiagnostics(diags)
+	}
+	config, err := listConfig(req.Config.Raw)
+	if err != nil {
+		failed("Invalid list config", err)
+		return
+	}
+	effectiveDefaults, _, err := extractEssentialFields("list", config, l.providerConfig)
+	if err != nil {
+		failed("Invalid list config", err)
+		return
+	}
+	filter, _ := config.Get("filter").(map[string]interface{})
+	entries, err := callList(effectiveDefaults, filter)
+	if err != nil {
+		failed("List failed", err)
+		return
+	}
+	if req.Limit > 0 && int64(len(entries)) > req.Limit {
+		entries = entries[:req.Limit]
+	}
+
+	results := make([]list.ListResult, 0, len(entries))
+	for _, entry := range entries {
+		result := req.NewListResult(ctx)
+		result.DisplayName = entry.ID
+		if result.Identity.Raw, err = identityObject(l.identityKeys, entry.ID, entry.Config); err != nil {
+			result.Diagnostics.AddError("Invalid list result", err.Error())
+		}
+		if req.IncludeResource {
+			if result.Resource.Raw, err = listResourceValue(result.Resource.Raw.Type(), config, entry); err != nil {
+				result.Diagnostics.AddError("Invalid list result", err.Error())
+			}
+		}
+		results = append(results, result)
+	}
+	stream.Results = slices.Values(results)
+}
+
+// listResourceValue - The resource object for an object found by the 'list' event
+func listResourceValue(resourceType tftypes.Type, config attributeMap, entry listEntry) (tftypes.Value, error) {
+	values := map[string]tftypes.Value{}
+	for name, typ := range resourceType.(tftypes.Object).AttributeTypes {
+		values[name] = tftypes.NewValue(typ, nil)
+	}
+	values["id"] = tftypes.NewValue(tftypes.String, entry.ID)
+	for _, name := range []string{"executor", "script", "id_key"} {
+		if v, ok := config.GetOk(name); ok {
+			values[name] = tftypes.NewValue(tftypes.String, v)
+		}
+	}
+	configValue, err := jsonToValue(entry.Config)
+	if err != nil {
+		return tftypes.Value{}, err
+	}
+	values["config"] = configValue
+	return tftypes.NewValue(resourceType, values), nil
+}
@@ -0,0 +1,69 @@
+package universe
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"testing"
+)
+
+func Test_ProviderServerListResource(t *testing.T) {
+	s := newTestServer(t)
+	testConfigureServer(t, s)
+	schemaResp, _ := s.GetProviderSchema(context.Background(), &tfprotov5.GetProviderSchemaRequest{})
+	if _, ok := schemaResp.ListResourceSchemas[DefaultProviderName]; !ok {
+		t.Fatal("expected a list resource schema")
+	}
+	listType := listResourceSchema().Type().TerraformType(context.Background())
+	config, err := tfprotov5.NewDynamicValue(listType, tftypes.NewValue(listType, map[string]tftypes.Value{
+		"executor": tftypes.NewValue(tftypes.String, nil),
+		"script":   tftypes.NewValue(tftypes.String, nil),
+		"id_key":   tftypes.NewValue(tftypes.String, nil),
+		"filter": tftypes.NewValue(tftypes.Object{AttributeTypes: map[string]tftypes.Type{"album": tftypes.String}},
+			map[string]tftypes.Value{"album": tftypes.NewValue(tftypes.String, "white")}),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls, ok := s.ProviderServer.(tfprotov5.ProviderServerWithListResource)
+	if !ok {
+		t.Fatal("expected the provider to serve list resources")
+	}
+	validate, err := ls.ValidateListResourceConfig(context.Background(), &tfprotov5.ValidateListResourceConfigRequest{
+		TypeName: DefaultProviderName,
+		Config:   &config,
+	})
+	if err != nil || len(validate.Diagnostics) != 0 {
+		t.Fatalf("validate failed %#v %#v", err, validate.Diagnostics)
+	}
+	stream, err := ls.ListResource(context.Background(), &tfprotov5.ListResourceRequest{
+		TypeName:        DefaultProviderName,
+		Config:          &config,
+		IncludeResource: true,
+		Limit:           1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results []tfprotov5.ListResourceResult
+	for r := range stream.Results {
+		results = append(results, r)
+	}
+	if len(results) != 1 || len(results[0].Diagnostics) != 0 || results[0].DisplayName != "42" {
+		t.Fatalf("unexpected results %#v", results)
+	}
+	if id := testIdentityID(t, results[0].Identity); id != "42" {
+		t.Errorf("unexpected identity %s", id)
+	}
+	value, err := results[0].Resource.Unmarshal(s.resourceType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attrs := map[string]tftypes.Value{}
+	_ = value.As(&attrs)
+	resourceConfig := map[string]tftypes.Value{}
+	err = attrs["config"].As(&resourceConfig)
+	if err != nil || !resourceConfig["album"].Equal(tftypes.NewValue(tftypes.String, "white")) {
+		t.Errorf("unexpected config %s", attrs["config"])
+	}
+}
@@ -0,0 +1,16 @@
+import sys
+import json
+
+if __name__ == '__main__':
+    event = sys.argv[1]
+    entre = sys.stdin.read()
+    if event == "capabilities":
+        print(json.dumps({"events": ["create", "read", "update", "delete"], "protocol_version": 1,
+                          "features": ["mutable_id"]}))
+        exit(0)
+    if event == "delete":
+        exit(0)
+    input_dict = json.loads(entre)
+    # Update re-keys the object
+    input_dict.update({"id": "43" if event == "update" else "42"})
+    print(json.dumps(input_dict))
@@ -0,0 +1,138 @@
+package universe
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// normalizeRules - Rules applied to a decoded config before two configs are compared.
+type normalizeRules struct {
+	// NumericCoercion - strings which parse as numbers are compared as numbers, so "20" == 20
+	NumericCoercion bool
+	// UnorderedKeys - arrays held in these keys are compared as sets, the order of elements is ignored
+	UnorderedKeys map[string]bool
+	// CaseInsensitiveKeys - string values held in these keys are compared ignoring case
+	CaseInsensitiveKeys map[string]bool
+	// Format - the format the configs are decoded from, 'auto' when empty
+	Format string
+}
+
+// getNormalizeRules - Extract the normalisation rules from the resource attributes.
+func getNormalizeRules(d *resourceModel) normalizeRules {
+	rules := normalizeRules{
+		NumericCoercion:     d.NumericCoercion.ValueBool(),
+		UnorderedKeys:       map[string]bool{},
+		CaseInsensitiveKeys: map[string]bool{},
+		Format:              getConfigFormat(d),
+	}
+	for _, k := range stringList(d.UnorderedKeys) {
+		rules.UnorderedKeys[k] = true
+	}
+	for _, k := range stringList(d.CaseInsensitiveKeys) {
+		rules.CaseInsensitiveKeys[k] = true
+	}
+	return rules
+}
+
+// normalizeConfig - Decode a JSON/YAML/TOML config, drop the @ fields and apply the rules.
+// Returns a canonical JSON string which can be compared with another.
+func normalizeConfig(config string, rules normalizeRules) (string, error) {
+	jstr, err := decodeConfigFormat([]byte(config), rules.Format)
+	if err != nil {
+		return "", err
+	}
+	var x interface{}
+	err = json.Unmarshal(jstr, &x)
+	if err != nil {
+		return "", err
+	}
+	xmap, ok := x.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("expected map in config but got %#v", x)
+	}
+	for attrName := range xmap {
+		if strings.HasPrefix(attrName, "@") {
+			delete(xmap, attrName)
+		}
+	}
+	xbytes, err := json.Marshal(rules.apply("", xmap))
+	if err != nil {
+		return "", err
+	}
+	return string(xbytes), nil
+}
+
+// checkNormalize - Fail the plan when the config cannot be normalised, rather than hide its changes
+func checkNormalize(d *resourceModel) error {
+	if !configKnown(d) || d.Config.IsNull() || d.Config.IsUnderlyingValueNull() {
+		return nil
+	}
+	config, err := getConfigFromTF(d)
+	if err == nil {
+		_, err = normalizeConfig(string(config), getNormalizeRules(d))
+	}
+	if err != nil {
+		return fmt.Errorf("'config' cannot be compared: %w", err)
+	}
+	return nil
+}
+
+// apply - Recursively normalise a value decoded from JSON. key is the name of the
+// map key holding the value, or the key holding the enclosing array.
+func (rules normalizeRules) apply(key string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			result[k] = rules.apply(k, e)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, e := range v {
+			result[i] = rules.apply(key, e)
+		}
+		if rules.UnorderedKeys[key] {
+			sortByJSON(result)
+		}
+		return result
+	case string:
+		if rules.NumericCoercion {
+			// "nan" and "inf" parse too, but JSON cannot hold them so they stay strings
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+				return f
+			}
+		}
+		if rules.CaseInsensitiveKeys[key] {
+			return strings.ToLower(v)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// sortByJSON - Sort the elements of an array by their JSON representation
+func sortByJSON(values []interface{}) {
+	keys := make(map[int]string, len(values))
+	for i, e := range values {
+		b, _ := json.Marshal(e)
+		keys[i] = string(b)
+	}
+	indexes := make([]int, len(values))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return keys[indexes[i]] < keys[indexes[j]]
+	})
+	sorted := make([]interface{}, len(values))
+	for i, idx := range indexes {
+		sorted[i] = values[idx]
+	}
+	copy(values, sorted)
+}
@@ -0,0 +1,114 @@
+package universe
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"testing"
+)
+
+func Test_normalizeConfigFormats(t *testing.T) {
+	rules := normalizeRules{}
+	fromJSON, err := normalizeConfig(`{"name": "x", "size": 1.0, "tags": ["a", "b"], "nested": {"on": true}}`, rules)
+	if err != nil {
+		t.FailNow()
+	}
+	fromYAML, err := normalizeConfig("name: x\nsize: 1\ntags:\n  - a\n  - b\nnested:\n  on: true\n", rules)
+	if err != nil {
+		t.FailNow()
+	}
+	fromTOML, err := normalizeConfig("name = \"x\"\nsize = 1\ntags = [\"a\", \"b\"]\n[nested]\non = true\n", rules)
+	if err != nil {
+		t.FailNow()
+	}
+	if fromJSON != fromYAML || fromJSON != fromTOML {
+		t.Errorf("expected equal configs:\n%s\n%s\n%s", fromJSON, fromYAML, fromTOML)
+	}
+}
+
+func Test_normalizeConfigNumericCoercion(t *testing.T) {
+	a, _ := normalizeConfig(`{"size": "20", "ratio": 1.0}`, normalizeRules{})
+	b, _ := normalizeConfig(`{"size": 20, "ratio": "1"}`, normalizeRules{})
+	if a == b {
+		t.Fail()
+	}
+	rules := normalizeRules{NumericCoercion: true}
+	a, _ = normalizeConfig(`{"size": "20", "ratio": 1.0}`, rules)
+	b, _ = normalizeConfig(`{"size": 20, "ratio": "1"}`, rules)
+	if a != b {
+		t.Errorf("expected %s == %s", a, b)
+	}
+}
+
+func Test_normalizeConfigUnorderedKeys(t *testing.T) {
+	rules := normalizeRules{UnorderedKeys: map[string]bool{"tags": true}}
+	a, _ := normalizeConfig(`{"tags": ["b", "a"], "order": ["b", "a"], "nested": {"tags": [{"k": 2}, {"k": 1}]}}`, rules)
+	b, _ := normalizeConfig(`{"tags": ["a", "b"], "order": ["b", "a"], "nested": {"tags": [{"k": 1}, {"k": 2}]}}`, rules)
+	if a != b {
+		t.Errorf("expected %s == %s", a, b)
+	}
+	c, _ := normalizeConfig(`{"tags": ["a", "b"], "order": ["a", "b"]}`, rules)
+	d, _ := normalizeConfig(`{"tags": ["a", "b"], "order": ["b", "a"]}`, rules)
+	if c == d {
+		t.Fail()
+	}
+}
+
+func Test_normalizeConfigCaseInsensitiveKeys(t *testing.T) {
+	rules := normalizeRules{CaseInsensitiveKeys: map[string]bool{"region": true, "zones": true}}
+	a, _ := normalizeConfig(`{"region": "EU-West-1", "zones": ["A", "b"], "name": "Abc"}`, rules)
+	b, _ := normalizeConfig(`{"region": "eu-west-1", "zones": ["a", "B"], "name": "Abc"}`, rules)
+	if a != b {
+		t.Errorf("expected %s == %s", a, b)
+	}
+	c, _ := normalizeConfig(`{"name": "Abc"}`, rules)
+	d, _ := normalizeConfig(`{"name": "abc"}`, rules)
+	if c == d {
+		t.Fail()
+	}
+}
+
+func Test_getNormalizeRules(t *testing.T) {
+	d := testResource("", `{}`)
+	d.NumericCoercion = types.BoolValue(true)
+	d.UnorderedKeys = types.ListValueMust(types.StringType, []attr.Value{types.StringValue("tags")})
+	d.CaseInsensitiveKeys = types.ListValueMust(types.StringType, []attr.Value{types.StringValue("region")})
+	rules := getNormalizeRules(d)
+	if !rules.NumericCoercion || !rules.UnorderedKeys["tags"] || !rules.CaseInsensitiveKeys["region"] {
+		t.Fail()
+	}
+	rules = getNormalizeRules(testResource("", `{}`))
+	if rules.NumericCoercion || len(rules.UnorderedKeys) != 0 || len(rules.CaseInsensitiveKeys) != 0 {
+		t.Fail()
+	}
+}
+
+func Test_normalizeConfigNonFinite(t *testing.T) {
+	rules := normalizeRules{NumericCoercion: true}
+	for _, mode := range []string{"nan", "NaN", "inf", "-Inf", "infinity"} {
+		a, err := normalizeConfig(`{"mode": "`+mode+`", "size": 1}`, rules)
+		if err != nil {
+			t.Errorf("expected '%s' to stay a string %#v", mode, err)
+		}
+		b, _ := normalizeConfig(`{"mode": "`+mode+`", "size": 2}`, rules)
+		if a == b {
+			t.Errorf("expected the change of size to be seen with '%s': %s == %s", mode, a, b)
+		}
+	}
+	d := testResource("", `{"mode": "nan", "size": 1}`)
+	d.NumericCoercion = types.BoolValue(true)
+	if diffSuppressComputed("config", `{"mode": "nan", "size": 1}`, `{"mode": "nan", "size": 2}`, d) {
+		t.Error("expected the change of size not to be suppressed")
+	}
+}
+
+func Test_checkNormalize(t *testing.T) {
+	d := testResource("42", `["not", "an", "object"]`)
+	if err := checkNormalize(d); err == nil {
+		t.Error("expected a config which cannot be normalised to fail the plan")
+	}
+	d = testResource("42", `{"mode": "inf"}`)
+	d.NumericCoercion = types.BoolValue(true)
+	if err := checkNormalize(d); err != nil {
+		t.Errorf("expected 'inf' to be compared as a string %#v", err)
+	}
+}
@@ -0,0 +1,214 @@
+package universe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// unknownVariableValue - The marker the provider uses for a value which is not known until apply.
+// Set in 'outputs' it becomes an unknown value in the plan, and so does a string in 'result' holding it.
+const unknownVariableValue = "74D93920-ED26-11E3-AC10-0800200C9A66"
+
+// planRequest - The JSON passed on stdin to the script for the 'plan' event
+type planRequest struct {
+	Prior    interface{} `json:"prior"`
+	Proposed interface{} `json:"proposed"`
+	Result   interface{} `json:"result"`
+}
+
+// planResponse - The JSON returned by the script for the 'plan' event
+type planResponse struct {
+	// Result - the predicted result after apply
+	Result map[string]interface{} `json:"result"`
+	// Unknown - keys or JSON pointers into the result which are not known until apply
+	Unknown []string `json:"unknown"`
+	// RequiresReplace - keys or JSON pointers into the config which cannot be updated in place
+	RequiresReplace []string `json:"requires_replace"`
+}
+
+// planEventEnabled - true if the provider or the resource has 'plan_event' set
+func planEventEnabled(d *resourceModel, providerConfig interface{}) bool {
+	defaults, _ := providerConfig.(map[string]interface{})
+	return attributeEnabled("plan_event", defaults, d.settings())
+}
+
+// planChange - Called when Terraform plans a change the script has to apply. Prior is nil on create.
+// The computed attributes are predicted by the script's 'plan' event if it is enabled, otherwise they
+// are unknown. Returns true when the change requires replacement.
+func planChange(prior, planned *resourceModel, providerConfig interface{}) (bool, error) {
+	capabilities, err := planCapabilities(planned, providerConfig)
+	if err != nil {
+		return false, err
+	}
+	if err = checkConfigFormat(planned); err != nil {
+		return false, err
+	}
+	if err = checkNormalize(planned); err != nil {
+		return false, err
+	}
+	replace := false
+	if prior != nil && !capabilities.supportsEvent("update") {
+		log.Printf("planChange() the script has no 'update' event, replacing the resource")
+		replace = true
+	} else if replace, err = replaceOnChange(prior, planned); err != nil {
+		return false, err
+	}
+	if planEventEnabled(planned, providerConfig) && configKnown(planned) {
+		replaceNeeded, err := callPlan(prior, planned, providerConfig)
+		return replace || replaceNeeded, err
+	}
+	setResultComputed(planned)
+	return replace, nil
+}
+
+// planCapabilities - Get the capabilities of the script and check the resource only uses what it supports.
+// Nothing is checked until the executor and script are known.
+func planCapabilities(d *resourceModel, providerConfig interface{}) (*scriptCapabilities, error) {
+	if d.Executor.IsUnknown() || d.Script.IsUnknown() {
+		return nil, nil
+	}
+	effectiveDefaults, _, err := extractEssentialFields("capabilities", d.settings(), providerConfig)
+	if err != nil {
+		log.Printf("planCapabilities() cannot run the script yet: %#v", err)
+		return nil, nil
+	}
+	capabilities := getCapabilities(effectiveDefaults)
+	return capabilities, checkCapabilities(capabilities, d.settings(), providerConfig)
+}
+
+// replaceOnChange - true when a key listed in 'replace_on_change' changes in the config. A config
+// which is not known until apply may change any of them.
+func replaceOnChange(prior, planned *resourceModel) (bool, error) {
+	pointers := stringList(planned.ReplaceOnChange)
+	if prior == nil || len(pointers) == 0 {
+		return false, nil
+	}
+	if !configKnown(planned) {
+		log.Printf("replaceOnChange() config unknown until apply, replacing the resource")
+		return true, nil
+	}
+	oldConfig, err := decodeConfig(prior)
+	if err != nil {
+		return false, err
+	}
+	newConfig, err := decodeConfig(planned)
+	if err != nil {
+		return false, err
+	}
+	if key, changed := changedPointer(oldConfig, newConfig, pointers); changed {
+		log.Printf("replaceOnChange() change in '%s' requires replacement", key)
+		return true, nil
+	}
+	return false, nil
+}
+
+// configKnown - true if nothing in 'config' is unknown until apply
+func configKnown(d *resourceModel) bool {
+	v, err := d.Config.ToTerraformValue(context.Background())
+	return err == nil && v.IsFullyKnown()
+}
+
+// changedPointer - Find the first key or JSON pointer whose value differs between the two documents
+func changedPointer(prior, proposed interface{}, pointers []string) (string, bool) {
+	for _, key := range pointers {
+		oldValue, _ := jsonPointerGet(prior, key)
+		newValue, _ := jsonPointerGet(proposed, key)
+		if !reflect.DeepEqual(oldValue, newValue) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// setResultComputed - Mark the computed attributes unknown until apply
+func setResultComputed(d *resourceModel) {
+	d.Result = types.DynamicUnknown()
+	d.Outputs = types.MapUnknown(types.StringType)
+	d.SensitiveResult = types.DynamicUnknown()
+}
+
+// decodeConfig - Decode 'config' into a JSON document
+func decodeConfig(d *resourceModel) (interface{}, error) {
+	configData, err := getConfigFromTF(d)
+	if err != nil {
+		return nil, err
+	}
+	var x interface{}
+	err = json.Unmarshal(configData, &x)
+	return x, err
+}
+
+// callPlan - Run the 'plan' event with the prior and proposed config and set the predicted result in the plan
+func callPlan(prior, planned *resourceModel, providerConfig interface{}) (bool, error) {
+	effectiveDefaults, id, err := extractEssentialFields("plan", planned.settings(), providerConfig)
+	if err != nil {
+		return false, err
+	}
+	request := planRequest{}
+	if prior != nil {
+		if request.Prior, err = decodeConfig(prior); err != nil {
+			return false, err
+		}
+		if !prior.Result.IsNull() {
+			if request.Result, err = dynamicToJSON(prior.Result); err != nil {
+				return false, err
+			}
+		}
+	}
+	if request.Proposed, err = decodeConfig(planned); err != nil {
+		return false, err
+	}
+	stdin, err := json.Marshal(request)
+	if err != nil {
+		return false, err
+	}
+	rawResponse, err := runScript("plan", id, effectiveDefaults, stdin)
+	if err != nil {
+		return false, err
+	}
+	log.Printf("callPlan() response: %s", redactResponse(rawResponse, sensitiveKeys(planned.settings())))
+	response := planResponse{}
+	if len(rawResponse) > 0 {
+		err = json.Unmarshal(rawResponse, &response)
+		if err != nil {
+			return false, fmt.Errorf("expecting plan response from subprocess, got '%s': %w", string(rawResponse), err)
+		}
+	}
+
+	replace := false
+	if prior != nil {
+		if key, changed := changedPointer(request.Prior, request.Proposed, response.RequiresReplace); changed {
+			log.Printf("callPlan() change in '%s' requires replacement", key)
+			replace = true
+		}
+	}
+
+	if response.Result == nil {
+		setResultComputed(planned)
+		return replace, nil
+	}
+	for _, key := range response.Unknown {
+		if err = jsonPointerSet(response.Result, key, unknownVariableValue); err != nil {
+			return false, err
+		}
+	}
+	splitSensitive(response.Result, sensitiveKeys(planned.settings())) // Known after apply
+	planned.SensitiveResult = types.DynamicUnknown()
+	if planned.Result, err = jsonToDynamic(response.Result); err != nil {
+		return false, err
+	}
+	outputs, err := flattenOutputs(response.Result)
+	if err != nil {
+		return false, err
+	}
+	for _, key := range response.Unknown {
+		outputs[splitJSONPointer(key)[0]] = unknownVariableValue
+	}
+	planned.Outputs, err = stringMapValue(outputs)
+	return replace, err
+}
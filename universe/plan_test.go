@@ -0,0 +1,128 @@
+package universe
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"testing"
+)
+
+var testPlanConfig = map[string]interface{}{
+	"id_key":     "id",
+	"executor":   "python3",
+	"script":     "resource_universe_test.py",
+	"plan_event": true,
+}
+
+func Test_planChangeCreate(t *testing.T) {
+	d := testResource("", `{"album": "white"}`)
+	replace, err := planChange(nil, d, testPlanConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, ok := d.Result.UnderlyingValue().(types.Object)
+	if !ok {
+		t.Fatalf("unexpected result %s", d.Result)
+	}
+	attributes := result.Attributes()
+	if attributes["album"].String() != `"white"` || attributes["id"].String() != `"42"` || !attributes["@created"].IsUnknown() {
+		t.Errorf("unexpected result %s", d.Result)
+	}
+	outputs := d.Outputs.Elements()
+	if outputs["album"].String() != `"white"` || !outputs["@created"].IsUnknown() {
+		t.Errorf("unexpected outputs %s", d.Outputs)
+	}
+	if replace {
+		t.Fail()
+	}
+}
+
+func Test_planChangeRequiresReplace(t *testing.T) {
+	prior := testResource("42", `{"album": "white", "year": 1968}`)
+	prior.Result = types.DynamicValue(types.StringValue("white"))
+	replace, err := planChange(prior, testResource("42", `{"album": "black", "year": 1968}`), testPlanConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !replace {
+		t.Fail()
+	}
+	replace, err = planChange(prior, testResource("42", `{"album": "white", "year": 1969}`), testPlanConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replace {
+		t.Fail()
+	}
+}
+
+func Test_planChangeDisabled(t *testing.T) {
+	config := map[string]interface{}{
+		"id_key":   "id",
+		"executor": "python3",
+		"script":   "resource_universe_test.py",
+	}
+	prior := testResource("42", `{"album": "white"}`)
+	prior.Result = types.DynamicValue(types.StringValue("white"))
+	d := testResource("42", `{"album": "black"}`)
+	d.Result = prior.Result
+	replace, err := planChange(prior, d, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Result.IsUnknown() || !d.Outputs.IsUnknown() || replace {
+		t.Fail()
+	}
+}
+
+func Test_jsonPointer(t *testing.T) {
+	doc := map[string]interface{}{
+		"metadata": map[string]interface{}{"a/b": "x"},
+		"items":    []interface{}{"first", "second"},
+	}
+	if v, ok := jsonPointerGet(doc, "/metadata/a~1b"); !ok || v != "x" {
+		t.Errorf("unexpected value %#v", v)
+	}
+	if v, ok := jsonPointerGet(doc, "/items/1"); !ok || v != "second" {
+		t.Errorf("unexpected value %#v", v)
+	}
+	if _, ok := jsonPointerGet(doc, "/items/2"); ok {
+		t.Fail()
+	}
+	if err := jsonPointerSet(doc, "/status/uid", "u"); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := jsonPointerGet(doc, "/status/uid"); !ok || v != "u" {
+		t.Errorf("unexpected value %#v", v)
+	}
+	if err := jsonPointerSet(doc, "/items/x", "u"); err == nil {
+		t.Fail()
+	}
+}
+
+func Test_planChangeReplaceOnChange(t *testing.T) {
+	config := map[string]interface{}{
+		"id_key":   "id",
+		"executor": "python3",
+		"script":   "resource_universe_test.py",
+	}
+	replaceOnChange := types.ListValueMust(types.StringType, []attr.Value{types.StringValue("/spec/zone")})
+	prior := testResource("42", `{"spec": {"zone": "a"}, "size": 1}`)
+	d := testResource("42", `{"spec": {"zone": "b"}, "size": 1}`)
+	d.ReplaceOnChange = replaceOnChange
+	replace, err := planChange(prior, d, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !replace {
+		t.Fail()
+	}
+	d = testResource("42", `{"spec": {"zone": "a"}, "size": 2}`)
+	d.ReplaceOnChange = replaceOnChange
+	replace, err = planChange(prior, d, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replace {
+		t.Fail()
+	}
+}
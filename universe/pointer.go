@@ -0,0 +1,80 @@
+package universe
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// splitJSONPointer - Split a JSON pointer (RFC 6901) such as '/metadata/uid' into its reference tokens.
+// A key without a leading '/' is treated as a single top level key.
+func splitJSONPointer(pointer string) []string {
+	if !strings.HasPrefix(pointer, "/") {
+		return []string{pointer}
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens
+}
+
+// jsonPointerGet - Get the value at the pointer in a document decoded from JSON
+func jsonPointerGet(doc interface{}, pointer string) (interface{}, bool) {
+	current := doc
+	for _, token := range splitJSONPointer(pointer) {
+		switch v := current.(type) {
+		case map[string]interface{}:
+			next, ok := v[token]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			current = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// jsonPointerSet - Set the value at the pointer in a document decoded from JSON, creating
+// intermediate objects when they are missing.
+func jsonPointerSet(doc map[string]interface{}, pointer string, value interface{}) error {
+	tokens := splitJSONPointer(pointer)
+	var current interface{} = doc
+	for n, token := range tokens {
+		last := n == len(tokens)-1
+		switch v := current.(type) {
+		case map[string]interface{}:
+			if last {
+				v[token] = value
+				return nil
+			}
+			next, ok := v[token]
+			if !ok || next == nil {
+				next = map[string]interface{}{}
+				v[token] = next
+			}
+			current = next
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return fmt.Errorf("invalid array index '%s' in '%s'", token, pointer)
+			}
+			if last {
+				v[i] = value
+				return nil
+			}
+			current = v[i]
+		default:
+			return fmt.Errorf("cannot set '%s', '%s' is not an object or array", pointer, token)
+		}
+	}
+	return nil
+}
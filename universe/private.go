@@ -0,0 +1,156 @@
+package universe
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"log"
+)
+
+// privateKey - The key of the provider's own data in the private state
+const privateKey = "universe"
+
+// idempotencyKeyVar - The environment variable holding the idempotency key for the 'create' event
+const idempotencyKeyVar = "UNIVERSE_IDEMPOTENCY_KEY"
+
+// idempotencySeedAttribute - Changes the idempotency key of an instance, it is not given to the script otherwise
+const idempotencySeedAttribute = "idempotency_seed"
+
+// scriptPrivateVar - The environment variable holding the '_private' object last returned by the script
+const scriptPrivateVar = "UNIVERSE_PRIVATE"
+
+// privateStateField - The field of the effective defaults holding the *privateState of the instance
+const privateStateField = "private_state"
+
+// privateState - Data kept for each resource instance in Terraform's private state, which is not shown in plans
+type privateState struct {
+	// Script - the '_private' object returned by the script, passed back to it on every event
+	Script json.RawMessage `json:"script,omitempty"`
+}
+
+// isEmpty - Nothing to keep in the private state
+func (p *privateState) isEmpty() bool {
+	return p == nil || len(p.Script) == 0
+}
+
+// privateData - The private state of an instance as the framework passes it to the resource
+type privateData interface {
+	GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics)
+	SetKey(ctx context.Context, key string, value []byte) diag.Diagnostics
+}
+
+// getPrivateState - Read the provider's data from the private state of the instance
+func getPrivateState(ctx context.Context, private privateData) (*privateState, diag.Diagnostics) {
+	p := &privateState{}
+	if private == nil {
+		return p, nil
+	}
+	data, diags := private.GetKey(ctx, privateKey)
+	if diags.HasError() || len(data) == 0 {
+		return p, diags
+	}
+	if err := json.Unmarshal(data, p); err != nil {
+		diags.AddError("Invalid private state", err.Error())
+	}
+	return p, diags
+}
+
+// setPrivateState - Write the provider's data into the private state of the instance, removing it when empty
+func setPrivateState(ctx context.Context, private privateData, p *privateState) diag.Diagnostics {
+	if private == nil {
+		return nil
+	}
+	if p.isEmpty() {
+		return private.SetKey(ctx, privateKey, nil)
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		var diags diag.Diagnostics
+		diags.AddError("Invalid private state", err.Error())
+		return diags
+	}
+	return private.SetKey(ctx, privateKey, data)
+}
+
+// idempotencyKey - The key passed to 'create', a hash of the resource type, the normalised config and the
+// 'idempotency_seed'. Every attempt to create the same instance gets the same key, even after a failed apply
+// left nothing in the state. Instances with the same config need different seeds to get different keys.
+func idempotencyKey(typeName string, d *resourceModel) (string, error) {
+	config, err := getConfigFromTF(d)
+	if err != nil {
+		return "", err
+	}
+	normalized, err := normalizeConfig(string(config), getNormalizeRules(d))
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256([]byte(typeName + "\x00" + normalized + "\x00" + d.IdempotencySeed.ValueString()))
+	return hex.EncodeToString(h[:]), nil
+}
+
+// withPrivate - Add the '_private' object of the instance to the environment of the script, where it is not
+// logged. The private state is kept in the effective defaults so a new '_private' object in the response can be
+// stored.
+func withPrivate(p *privateState, event string, providerConfig interface{}) interface{} {
+	if p == nil {
+		return providerConfig
+	}
+	config := withEnvironment(providerConfig, map[string]string{})
+	if len(p.Script) > 0 {
+		config = withSecretEnvironment(config, map[string]string{scriptPrivateVar: string(p.Script)})
+	}
+	config[privateStateField] = p
+	return config
+}
+
+// withEnvironment - Copy the provider configuration, adding the variables to its 'environment'
+func withEnvironment(providerConfig interface{}, vars map[string]string) map[string]interface{} {
+	config := map[string]interface{}{}
+	if defaults, ok := providerConfig.(map[string]interface{}); ok {
+		for k, v := range defaults {
+			config[k] = v
+		}
+	}
+	environment := map[string]interface{}{}
+	if env, ok := config["environment"].(map[string]interface{}); ok {
+		for k, v := range env {
+			environment[k] = v
+		}
+	}
+	for k, v := range vars {
+		environment[k] = v
+	}
+	config["environment"] = environment
+	return config
+}
+
+// takePrivate - Remove the '_private' object from the response and keep it in the private state,
+// so it is never shown in 'result' or plans. A null '_private' forgets it.
+func takePrivate(responseMap map[string]interface{}, effectiveDefaults map[string]interface{}) error {
+	v, ok := responseMap["_private"]
+	if !ok {
+		return nil
+	}
+	delete(responseMap, "_private")
+	p, ok := effectiveDefaults[privateStateField].(*privateState)
+	if !ok {
+		log.Printf("takePrivate() no private state to keep '_private' in")
+		return nil
+	}
+	if v == nil {
+		p.Script = nil
+		return nil
+	}
+	if _, ok := v.(map[string]interface{}); !ok {
+		return fmt.Errorf("expected an object in '_private' but got %#v", v)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	p.Script = b
+	return nil
+}
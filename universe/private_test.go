@@ -0,0 +1,195 @@
+package universe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+// testPrivateData - The private data the framework passes to the resource
+type testPrivateData map[string][]byte
+
+func (d testPrivateData) GetKey(_ context.Context, key string) ([]byte, diag.Diagnostics) {
+	return d[key], nil
+}
+
+func (d testPrivateData) SetKey(_ context.Context, key string, value []byte) diag.Diagnostics {
+	if len(value) == 0 {
+		delete(d, key)
+		return nil
+	}
+	d[key] = value
+	return nil
+}
+
+// testPrivateKey - The value of the provider's key in the private state returned by Terraform
+func testPrivateKey(t *testing.T, private []byte) []byte {
+	data := map[string][]byte{}
+	if len(private) > 0 {
+		if err := json.Unmarshal(private, &data); err != nil {
+			t.Fatalf("invalid private state %s: %#v", private, err)
+		}
+	}
+	return data[privateKey]
+}
+
+func Test_getSetPrivateState(t *testing.T) {
+	ctx := context.Background()
+	private := testPrivateData{}
+	if diags := setPrivateState(ctx, private, &privateState{Script: json.RawMessage(`{"handle":"h1"}`)}); diags.HasError() {
+		t.Fatal(diags)
+	}
+	p, diags := getPrivateState(ctx, private)
+	if diags.HasError() || string(p.Script) != `{"handle":"h1"}` {
+		t.Errorf("unexpected private state %#v %#v", p, diags)
+	}
+	_ = setPrivateState(ctx, private, &privateState{})
+	if _, ok := private[privateKey]; ok {
+		t.Errorf("expected an empty private state to be removed but got %s", private[privateKey])
+	}
+	private[privateKey] = []byte("{")
+	if _, diags = getPrivateState(ctx, private); !diags.HasError() {
+		t.Error("expected an error for an invalid private state")
+	}
+}
+
+func Test_idempotencyKey(t *testing.T) {
+	key, err := idempotencyKey("universe_queue", testResource("", `{"name": "work", "size": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if same, _ := idempotencyKey("universe_queue", testResource("", "size: 1\nname: work\n")); same != key {
+		t.Errorf("expected the same key for the same config written differently %s %s", key, same)
+	}
+	d := testResource("", `{"name": "work", "size": 1}`)
+	d.IdempotencySeed = types.StringValue("1")
+	for name, other := range map[string]*resourceModel{"seed": d, "config": testResource("", `{"name": "work", "size": 2}`)} {
+		if k, _ := idempotencyKey("universe_queue", other); k == key {
+			t.Errorf("expected another %s to give another key", name)
+		}
+	}
+	if k, _ := idempotencyKey("universe_topic", testResource("", `{"name": "work", "size": 1}`)); k == key {
+		t.Error("expected another resource type to give another key")
+	}
+}
+
+// testIdempotencyKey - The idempotency key the script got, echoed in the result
+func testIdempotencyKey(t *testing.T, s *testServer, apply *tfprotov5.ApplyResourceChangeResponse) string {
+	value, _ := apply.NewState.Unmarshal(s.resourceType)
+	attrs := map[string]tftypes.Value{}
+	_ = value.As(&attrs)
+	result := map[string]tftypes.Value{}
+	var key string
+	_ = attrs["result"].As(&result)
+	_ = result["@idempotency_key"].As(&key)
+	if key == "" {
+		t.Fatalf("expected the script to get an idempotency key but got %s", attrs["result"])
+	}
+	return key
+}
+
+func Test_ProviderServerIdempotencyKey(t *testing.T) {
+	s := newTestServer(t)
+	testConfigureServer(t, s)
+	config := testServerValue(t, s, map[string]tftypes.Value{"config": testObjectConfig()})
+	// A create which failed left nothing in the state, so the next apply plans and creates the instance again
+	first := testIdempotencyKey(t, s, testCreate(t, s, config))
+	if second := testIdempotencyKey(t, s, testCreate(t, s, config)); second != first {
+		t.Errorf("expected both attempts to get the same key %s %s", first, second)
+	}
+	seeded := testServerValue(t, s, map[string]tftypes.Value{
+		"config":           testObjectConfig(),
+		"idempotency_seed": tftypes.NewValue(tftypes.String, "1"),
+	})
+	if other := testIdempotencyKey(t, s, testCreate(t, s, seeded)); other == first {
+		t.Errorf("expected another seed to give another key %s", other)
+	}
+}
+
+func Test_takePrivate(t *testing.T) {
+	p := &privateState{}
+	response := map[string]interface{}{"id": "42", "_private": map[string]interface{}{"etag": "e1"}}
+	if err := takePrivate(response, map[string]interface{}{privateStateField: p}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := response["_private"]; ok || string(p.Script) != `{"etag":"e1"}` {
+		t.Errorf("expected _private to be moved to the private state %#v %s", response, p.Script)
+	}
+	if err := takePrivate(map[string]interface{}{"_private": nil}, map[string]interface{}{privateStateField: p}); err != nil || p.Script != nil {
+		t.Errorf("expected null to forget _private %#v %s", err, p.Script)
+	}
+	if err := takePrivate(map[string]interface{}{"_private": "x"}, map[string]interface{}{privateStateField: p}); err == nil {
+		t.Error("expected an error for a _private which is not an object")
+	}
+}
+
+func Test_callExecutorPrivate(t *testing.T) {
+	config := map[string]interface{}{
+		"id_key":   "id",
+		"executor": "python3",
+		"script":   "resource_universe_test.py",
+	}
+	p := &privateState{}
+	d := testResource("", `{"album": "white", "remember": "h1"}`)
+	if _, err := callExecutor("create", d, withPrivate(p, "create", config)); err != nil {
+		t.Fatal(err)
+	}
+	if string(p.Script) != `{"handle":"h1"}` {
+		t.Errorf("expected _private to be kept but got %s", p.Script)
+	}
+	if r := testJSON(t, d.Result); strings.Contains(r, "_private") {
+		t.Errorf("expected no _private in the result %s", r)
+	}
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	if _, err := callExecutor("read", d, withPrivate(p, "read", config)); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(logs.String(), scriptPrivateVar+"=") {
+		t.Errorf("expected %s not to be logged", scriptPrivateVar)
+	}
+	if r := testJSON(t, d.Result); !strings.Contains(r, `"@handle":"h1"`) {
+		t.Errorf("expected the script to get _private back but got %s", r)
+	}
+}
+
+func Test_privateLaterEvents(t *testing.T) {
+	config := map[string]interface{}{
+		"id_key":         "id",
+		"executor":       "python3",
+		"script":         "resource_universe_test.py",
+		"config_version": 2,
+	}
+	p := &privateState{Script: json.RawMessage(`{"handle":"h1"}`)}
+	d := testResource("42", `{"title": "white"}`)
+	if err := onRead(d, p, config); err != nil {
+		t.Fatal(err)
+	}
+	if c := testJSON(t, d.Config); !strings.Contains(c, `"handle":"h1"`) {
+		t.Errorf("expected the upgrade event to get _private but got %s", c)
+	}
+	planned := testResource("42", `{"album": "white"}`)
+	if _, err := callPlan(nil, planned, withPrivate(p, "plan", config)); err != nil {
+		t.Fatal(err)
+	}
+	if r, ok := planned.Result.UnderlyingValue().(types.Object); !ok || !r.Attributes()["@handle"].Equal(types.StringValue("h1")) {
+		t.Errorf("expected the plan event to get _private but got %s", planned.Result)
+	}
+	// The script says an instance it has been told is gone no longer exists
+	gone := testResource("42", `{"album": "white"}`)
+	if err := onRead(gone, &privateState{Script: json.RawMessage(`{"handle":"gone"}`)}, config); err != nil {
+		t.Fatal(err)
+	}
+	if !gone.ID.IsNull() {
+		t.Errorf("expected the exists event to get _private but got id %s", gone.ID)
+	}
+}
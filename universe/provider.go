@@ -3,11 +3,18 @@ package universe
 import (
 	"context"
 	"fmt"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -64,71 +71,170 @@ func getResourceTypeNamesFromEnvironment(providerName string) (result map[string
 	return
 }
 
-func getResourceMap(providerName string) (result map[string]*schema.Resource) {
-	result = make(map[string]*schema.Resource)
-	for resourceName := range getResourceTypeNamesFromEnvironment(providerName) {
-		result[resourceName] = resourceCustom()
+// universeProvider - A resource, a list resource and an ephemeral resource for each resource type, and the functions
+type universeProvider struct {
+	name          string
+	resourceTypes []string
+}
+
+var (
+	_ provider.ProviderWithFunctions          = &universeProvider{}
+	_ provider.ProviderWithListResources      = &universeProvider{}
+	_ provider.ProviderWithEphemeralResources = &universeProvider{}
+)
+
+// providerModel - The provider configuration, the defaults of every resource
+type providerModel struct {
+	IDKey            types.String `tfsdk:"id_key"`
+	IDSeparator      types.String `tfsdk:"id_separator"`
+	Executor         types.String `tfsdk:"executor"`
+	Script           types.String `tfsdk:"script"`
+	Environment      types.Map    `tfsdk:"environment"`
+	PlanEvent        types.Bool   `tfsdk:"plan_event"`
+	DeleteEmptyStdin types.Bool   `tfsdk:"delete_empty_stdin"`
+	ConfigVersion    types.Int64  `tfsdk:"config_version"`
+}
+
+// settings - The attributes which are set, as providerConfigure reads them
+func (m *providerModel) settings() attributeMap {
+	settings := attributeMap{}
+	for name, value := range map[string]types.String{"id_key": m.IDKey, "id_separator": m.IDSeparator, "executor": m.Executor, "script": m.Script} {
+		if !value.IsNull() && !value.IsUnknown() {
+			settings[name] = value.ValueString()
+		}
 	}
-	log.Printf("resourceMap is: %#v\n", result)
-	return
+	if !m.Environment.IsNull() && !m.Environment.IsUnknown() {
+		environment := map[string]interface{}{}
+		for k, v := range m.Environment.Elements() {
+			if s, ok := v.(types.String); ok {
+				environment[k] = s.ValueString()
+			}
+		}
+		settings["environment"] = environment
+	}
+	for name, value := range map[string]types.Bool{"plan_event": m.PlanEvent, "delete_empty_stdin": m.DeleteEmptyStdin} {
+		if !value.IsNull() && !value.IsUnknown() {
+			settings[name] = value.ValueBool()
+		}
+	}
+	if !m.ConfigVersion.IsNull() && !m.ConfigVersion.IsUnknown() {
+		settings["config_version"] = int(m.ConfigVersion.ValueInt64())
+	}
+	return settings
 }
 
 // Provider ...
-func Provider() *schema.Provider {
+func Provider() provider.Provider {
 	// Get the provider name to use
 	providerName := getProviderNameFromBinaryOrEnvironment()
 	log.Printf("universe provider name is: %s\n", providerName)
 
 	// Get the resource names
-	resourceMap := getResourceMap(providerName)
-	for n := range resourceMap {
+	p := &universeProvider{name: providerName}
+	for n := range getResourceTypeNamesFromEnvironment(providerName) {
 		log.Printf("provider %s has resource %s\n", providerName, n)
+		p.resourceTypes = append(p.resourceTypes, n)
 	}
+	sort.Strings(p.resourceTypes)
+	return p
+}
+
+func (p *universeProvider) Metadata(_ context.Context, _ provider.MetadataRequest, resp *provider.MetadataResponse) {
+	resp.TypeName = p.name
+}
 
-	p := &schema.Provider{
-		ConfigureContextFunc: providerConfigureV2,
-		ResourcesMap:         resourceMap,
-		Schema: map[string]*schema.Schema{
-			"id_key": {
-				Description: "The name of the key which holds the unique identifier of the resource. e.g. 'id'",
-				Type:        schema.TypeString,
+func (p *universeProvider) Schema(_ context.Context, _ provider.SchemaRequest, resp *provider.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"id_key": schema.StringAttribute{
+				Description: "The name of the key which holds the unique identifier of the resource. e.g. 'id'. A JSON pointer such as '/metadata/uid', or several keys separated by commas.",
+				Optional:    true,
+			},
+			"id_separator": schema.StringAttribute{
+				Description: "Joins the values of several keys in 'id_key' into the id. The default is '/'.",
 				Optional:    true,
 			},
-			"executor": {
+			"executor": schema.StringAttribute{
 				Description: "The name of the program to run. e.g. python",
-				Type:        schema.TypeString,
 				Optional:    true,
 			},
-			"script": {
+			"script": schema.StringAttribute{
 				Description: "The path to the script passed as the first argument to 'executor'.",
-				Type:        schema.TypeString,
 				Optional:    true,
 			},
-			"environment": {
+			"environment": schema.MapAttribute{
 				Description: "The configuration passed as environment variables to the provider script.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"plan_event": schema.BoolAttribute{
+				Description: "Call the script with the 'plan' event for every resource of the provider.",
+				Optional:    true,
+			},
+			"config_version": schema.Int64Attribute{
+				Description: "The version of the shape of 'config' the script expects. State with another version is passed to the 'upgrade' event.",
+				Optional:    true,
+			},
+			"delete_empty_stdin": schema.BoolAttribute{
+				Description: "Send nothing on stdin for the 'delete' event, as older versions did, instead of the config of the resource.",
 				Optional:    true,
-				Type:        schema.TypeMap,
-				Elem: &schema.Schema{
-					Type: schema.TypeString,
-				},
 			},
 		},
 	}
-	return p
 }
 
-// providerConfigureV2 - Map to normal function without lame, untestable v2 Diagnostics
-func providerConfigureV2(_ context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
-	result, err := providerConfigure(d)
+// Configure - The provider configuration is the defaults of every resource, list resource and ephemeral resource
+func (p *universeProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
+	var config providerModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	result, err := providerConfigure(config.settings())
 	if err != nil {
-		return result, diag.FromErr(err)
+		resp.Diagnostics.AddError("Invalid provider configuration", err.Error())
+		return
+	}
+	resp.ResourceData = result
+	resp.EphemeralResourceData = result
+	resp.ListResourceData = result
+}
+
+func (p *universeProvider) Resources(_ context.Context) []func() resource.Resource {
+	resources := make([]func() resource.Resource, 0, len(p.resourceTypes))
+	for _, typeName := range p.resourceTypes {
+		resources = append(resources, func() resource.Resource { return newUniverseResource(typeName) })
 	}
-	return result, nil
+	return resources
+}
+
+func (p *universeProvider) DataSources(_ context.Context) []func() datasource.DataSource {
+	return nil
+}
+
+func (p *universeProvider) EphemeralResources(_ context.Context) []func() ephemeral.EphemeralResource {
+	resources := make([]func() ephemeral.EphemeralResource, 0, len(p.resourceTypes))
+	for _, typeName := range p.resourceTypes {
+		resources = append(resources, func() ephemeral.EphemeralResource { return &ephemeralResource{typeName: typeName} })
+	}
+	return resources
+}
+
+func (p *universeProvider) ListResources(_ context.Context) []func() list.ListResource {
+	resources := make([]func() list.ListResource, 0, len(p.resourceTypes))
+	for _, typeName := range p.resourceTypes {
+		resources = append(resources, func() list.ListResource { return newListResource(typeName) })
+	}
+	return resources
+}
+
+func (p *universeProvider) Functions(_ context.Context) []func() function.Function {
+	return functions()
 }
 
-func providerConfigure(d ResourceLike) (interface{}, error) {
+func providerConfigure(d ResourceGetter) (interface{}, error) {
 	configurationData := map[string]interface{}{}
-	for _, key := range []string{"id_key", "executor", "script", "environment", "javascript"} {
+	for _, key := range []string{"id_key", "id_separator", "executor", "script", "environment", "plan_event", "delete_empty_stdin", "config_version", "javascript"} {
 		val, ok := d.GetOk(key)
 		if !ok {
 			continue
@@ -1,7 +1,10 @@
 package universe
 
 import (
-	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -10,8 +13,10 @@ import (
 )
 
 func TestProvider(t *testing.T) {
-	if err := Provider().InternalValidate(); err != nil {
-		t.Fatalf("err: %s", err)
+	s := providerserver.NewProtocol5(Provider())()
+	resp, err := s.GetProviderSchema(context.Background(), &tfprotov5.GetProviderSchemaRequest{})
+	if err != nil || len(resp.Diagnostics) != 0 {
+		t.Fatalf("err: %#v %#v", err, resp.Diagnostics)
 	}
 }
 
@@ -91,11 +96,17 @@ func TestGetResourceTypeNamesFromEnvironment(t *testing.T) {
 }
 
 func TestAccPreCheck(t *testing.T) {
-
-	testAccProvider := Provider()
-	err := testAccProvider.Configure(nil, terraform.NewResourceConfigRaw(map[string]interface{}{}))
-	if err != nil {
-		t.Fatal(err)
+	s := newTestServer(t)
+	schemaResp, _ := s.GetProviderSchema(context.Background(), &tfprotov5.GetProviderSchemaRequest{})
+	providerType := schemaResp.Provider.ValueType()
+	values := map[string]tftypes.Value{}
+	for name, typ := range providerType.(tftypes.Object).AttributeTypes {
+		values[name] = tftypes.NewValue(typ, nil)
+	}
+	config, _ := tfprotov5.NewDynamicValue(providerType, tftypes.NewValue(providerType, values))
+	resp, err := s.ConfigureProvider(context.Background(), &tfprotov5.ConfigureProviderRequest{Config: &config})
+	if err != nil || len(resp.Diagnostics) != 0 {
+		t.Fatalf("err: %#v %#v", err, resp.Diagnostics)
 	}
 }
 func TestDiffSuppressComputed(t *testing.T) {
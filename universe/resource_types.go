@@ -1,10 +1,30 @@
 package universe
 
-// ResourceLike - An interface with the schema.ResourceData methods actually used in the provider
-type ResourceLike interface {
+// ResourceGetter - An interface with the read methods used to find the settings of a resource
+type ResourceGetter interface {
 	Id() string
-	SetId(v string)
-	Set(key string, value interface{}) error
 	GetOk(key string) (interface{}, bool)
 	Get(key string) interface{}
 }
+
+// ResourceLike - An interface with the resource data methods used in the tests
+type ResourceLike interface {
+	ResourceGetter
+	SetId(v string)
+	Set(key string, value interface{}) error
+}
+
+// attributeMap - A ResourceGetter over attributes decoded from Terraform, e.g. the settings of a resource
+type attributeMap map[string]interface{}
+
+func (a attributeMap) Id() string {
+	id, _ := a["id"].(string)
+	return id
+}
+func (a attributeMap) GetOk(key string) (interface{}, bool) {
+	v, ok := a[key]
+	return v, ok && v != nil
+}
+func (a attributeMap) Get(key string) interface{} {
+	return a[key]
+}
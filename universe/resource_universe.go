@@ -2,95 +2,715 @@ package universe
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/BurntSushi/toml"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/dynamicplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"gopkg.in/yaml.v3"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
-func resourceCustom() *schema.Resource {
-	return &schema.Resource{
-		Create: onCreate,
-		Read:   onRead,
-		Update: onUpdate,
-		Delete: onDelete,
-		Exists: onExists,
+// setIdentity - Set the identity of the resource once it is saved
+func (r *universeResource) setIdentity(identity *tfsdk.ResourceIdentity, d *resourceModel, diags *diag.Diagnostics) {
+	missing, err := setIdentity(identity, d, r.identityKeys)
+	if err != nil {
+		diags.AddError("Invalid identity", err.Error())
+	}
+	for _, err = range missing {
+		diags.AddWarning("Incomplete identity", err.Error()+", it is left empty until the script returns it")
+	}
+}
 
-		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
-		},
+// universeResource - A resource type whose events are run by the script
+type universeResource struct {
+	typeName string
+	// identityKeys - the keys in the result which identify an object, see getIdentityKeysFromEnvironment
+	identityKeys []string
+	// providerConfig - the provider configuration, once the provider is configured
+	providerConfig interface{}
+}
+
+var (
+	_ resource.ResourceWithConfigure      = &universeResource{}
+	_ resource.ResourceWithValidateConfig = &universeResource{}
+	_ resource.ResourceWithModifyPlan     = &universeResource{}
+	_ resource.ResourceWithImportState    = &universeResource{}
+	_ resource.ResourceWithUpgradeState   = &universeResource{}
+	_ resource.ResourceWithIdentity       = &universeResource{}
+)
+
+// resourceModel - The attributes of a resource. 'config' is an object or a JSON/YAML/TOML string,
+// 'result' is the object returned by the script.
+type resourceModel struct {
+	ID                  types.String  `tfsdk:"id"`
+	Executor            types.String  `tfsdk:"executor"`
+	Script              types.String  `tfsdk:"script"`
+	Config              types.Dynamic `tfsdk:"config"`
+	ConfigFormat        types.String  `tfsdk:"config_format"`
+	IDKey               types.String  `tfsdk:"id_key"`
+	IDSeparator         types.String  `tfsdk:"id_separator"`
+	Result              types.Dynamic `tfsdk:"result"`
+	Outputs             types.Map     `tfsdk:"outputs"`
+	PlanEvent           types.Bool    `tfsdk:"plan_event"`
+	ReplaceOnChange     types.List    `tfsdk:"replace_on_change"`
+	Triggers            types.Map     `tfsdk:"triggers"`
+	NumericCoercion     types.Bool    `tfsdk:"numeric_coercion"`
+	UnorderedKeys       types.List    `tfsdk:"unordered_keys"`
+	CaseInsensitiveKeys types.List    `tfsdk:"case_insensitive_keys"`
+	DeletionProtection  types.Bool    `tfsdk:"deletion_protection"`
+	OnDestroy           types.String  `tfsdk:"on_destroy"`
+	IdempotencySeed     types.String  `tfsdk:"idempotency_seed"`
+	ConfigVersion       types.Int64   `tfsdk:"config_version"`
+	SensitiveKeys       types.List    `tfsdk:"sensitive_keys"`
+	SensitiveResult     types.Dynamic `tfsdk:"sensitive_result"`
+	SecretConfig        types.Dynamic `tfsdk:"secret_config"`
+	SecretEnvironment   types.Dynamic `tfsdk:"secret_environment"`
+	SecretVersion       types.String  `tfsdk:"secret_version"`
+}
+
+// nullResourceModel - A resource with every attribute null, for when Terraform gives no state to start from
+func nullResourceModel() resourceModel {
+	return resourceModel{
+		ID:                  types.StringNull(),
+		Executor:            types.StringNull(),
+		Script:              types.StringNull(),
+		Config:              types.DynamicNull(),
+		ConfigFormat:        types.StringNull(),
+		IDKey:               types.StringNull(),
+		IDSeparator:         types.StringNull(),
+		Result:              types.DynamicNull(),
+		Outputs:             types.MapNull(types.StringType),
+		PlanEvent:           types.BoolNull(),
+		ReplaceOnChange:     types.ListNull(types.StringType),
+		Triggers:            types.MapNull(types.StringType),
+		NumericCoercion:     types.BoolNull(),
+		UnorderedKeys:       types.ListNull(types.StringType),
+		CaseInsensitiveKeys: types.ListNull(types.StringType),
+		DeletionProtection:  types.BoolNull(),
+		OnDestroy:           types.StringNull(),
+		IdempotencySeed:     types.StringNull(),
+		ConfigVersion:       types.Int64Null(),
+		SensitiveKeys:       types.ListNull(types.StringType),
+		SensitiveResult:     types.DynamicNull(),
+		SecretConfig:        types.DynamicNull(),
+		SecretEnvironment:   types.DynamicNull(),
+		SecretVersion:       types.StringNull(),
+	}
+}
+
+// computedAttributes - The attributes set from the response of the script
+var computedAttributes = []string{"id", "result", "outputs", "sensitive_result", "config_version"}
+
+// settings - The id, the script settings and the features of the resource which are set, see
+// extractEssentialFields and checkCapabilities
+func (d *resourceModel) settings() attributeMap {
+	settings := attributeMap{}
+	for name, value := range map[string]types.String{"id": d.ID, "executor": d.Executor, "script": d.Script, "id_key": d.IDKey, "id_separator": d.IDSeparator} {
+		if !value.IsNull() && !value.IsUnknown() {
+			settings[name] = value.ValueString()
+		}
+	}
+	if !d.PlanEvent.IsNull() && !d.PlanEvent.IsUnknown() {
+		settings["plan_event"] = d.PlanEvent.ValueBool()
+	}
+	if pointers := stringList(d.ReplaceOnChange); len(pointers) > 0 {
+		replaceOnChange := make([]interface{}, 0, len(pointers))
+		for _, p := range pointers {
+			replaceOnChange = append(replaceOnChange, p)
+		}
+		settings["replace_on_change"] = replaceOnChange
+	}
+	if keys := stringList(d.SensitiveKeys); len(keys) > 0 {
+		sensitiveKeys := make([]interface{}, 0, len(keys))
+		for _, k := range keys {
+			sensitiveKeys = append(sensitiveKeys, k)
+		}
+		settings["sensitive_keys"] = sensitiveKeys
+	}
+	for name, value := range map[string]types.Dynamic{"secret_config": d.SecretConfig, "secret_environment": d.SecretEnvironment} {
+		if !value.IsNull() && !value.IsUnderlyingValueNull() {
+			settings[name] = true // Only set in the configuration, the values are not needed
+		}
+	}
+	return settings
+}
+
+// stringList - The known strings in a list attribute
+func stringList(list types.List) []string {
+	var result []string
+	for _, e := range list.Elements() {
+		if s, ok := e.(types.String); ok && !s.IsNull() && !s.IsUnknown() {
+			result = append(result, s.ValueString())
+		}
+	}
+	return result
+}
 
-		SchemaVersion: 1,
+func newUniverseResource(typeName string) resource.Resource {
+	return &universeResource{typeName: typeName, identityKeys: getIdentityKeysFromEnvironment(typeName)}
+}
+
+func (r *universeResource) Metadata(_ context.Context, _ resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = r.typeName
+	resp.ResourceBehavior.MutableIdentity = true // Identity keys in the result may change on update and read
+}
+
+func (r *universeResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = resourceSchema()
+}
+
+func (r *universeResource) Configure(_ context.Context, req resource.ConfigureRequest, _ *resource.ConfigureResponse) {
+	if req.ProviderData != nil {
+		r.providerConfig = req.ProviderData
+	}
+}
+
+func resourceSchema() schema.Schema {
+	return schema.Schema{
+		Version: 2,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description:   "The value of the 'id_key' field in the response of the script.",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
 
-		Schema: map[string]*schema.Schema{
-			"executor": {
+			"executor": schema.StringAttribute{
 				Description: "The name of the program to run. e.g. python",
-				Type:        schema.TypeString,
 				Optional:    true,
 			},
 
-			"script": {
+			"script": schema.StringAttribute{
 				Description: "The path to the script passed as the first argument to 'executor'.",
-				Type:        schema.TypeString,
 				Optional:    true,
 			},
 
-			"config": {
-				Description:      "The information (in JSON format) managed by Terraform plan and apply.",
-				Type:             schema.TypeString,
-				Required:         true,
-				ValidateFunc:     validation.StringIsNotWhiteSpace,
-				DiffSuppressFunc: diffSuppressComputed,
+			"config": schema.DynamicAttribute{
+				Description: "The information managed by Terraform plan and apply. An object, or a JSON/YAML/TOML string.",
+				Required:    true,
 			},
 
-			"id_key": {
-				Description:  "The name of the key which holds the unique identifier of the resource. e.g. 'id'",
-				Type:         schema.TypeString,
-				Optional:     true,
-				ValidateFunc: validation.StringIsNotWhiteSpace,
+			"config_format": schema.StringAttribute{
+				Description: "The format of 'config' when it is a string: 'auto' (the default) tries JSON, YAML and TOML in turn, any other format is the only one accepted: " + strings.Join(configFormats[1:], ", ") + ".",
+				Optional:    true,
+			},
+
+			"id_key": schema.StringAttribute{
+				Description: "The name of the key which holds the unique identifier of the resource. e.g. 'id'. A JSON pointer such as '/metadata/uid', or several keys separated by commas.",
+				Optional:    true,
+			},
+
+			"id_separator": schema.StringAttribute{
+				Description: "Joins the values of several keys in 'id_key' into the id. The default is '/'.",
+				Optional:    true,
+			},
+
+			"result": schema.DynamicAttribute{
+				Description:   "The object returned by the script on the last create, read or update.",
+				Computed:      true,
+				PlanModifiers: []planmodifier.Dynamic{dynamicplanmodifier.UseStateForUnknown()},
+			},
+
+			"secret_config": schema.DynamicAttribute{
+				Description: "Secret configuration for the script, an object or a JSON/YAML/TOML string. It is passed as JSON in UNIVERSE_SECRET_CONFIG on create and update and never stored in the state.",
+				Optional:    true,
+				WriteOnly:   true,
+			},
+
+			"secret_environment": schema.DynamicAttribute{
+				Description: "Secret environment variables for the script, passed on create and update and never stored in the state.",
+				Optional:    true,
+				WriteOnly:   true,
+			},
+
+			"secret_version": schema.StringAttribute{
+				Description: "Change this to send new values of 'secret_config' and 'secret_environment' to the script with the 'update' event.",
+				Optional:    true,
+			},
+
+			"sensitive_keys": schema.ListAttribute{
+				Description: "Names of the top level keys in the response which are sensitive. They are stored in 'sensitive_result' instead of 'result'.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+
+			"sensitive_result": schema.DynamicAttribute{
+				Description:   "The sensitive fields returned by the script, those listed in 'sensitive_keys' or named with a '!' prefix, which is removed.",
+				Computed:      true,
+				Sensitive:     true,
+				PlanModifiers: []planmodifier.Dynamic{dynamicplanmodifier.UseStateForUnknown()},
+			},
+
+			"config_version": schema.Int64Attribute{
+				Description:   "The version of the shape of 'config' stored in the state, see 'config_version' in the provider.",
+				Computed:      true,
+				PlanModifiers: []planmodifier.Int64{int64planmodifier.UseStateForUnknown()},
+			},
+
+			"outputs": schema.MapAttribute{
+				Description:   "The top level fields returned by the script, as strings. Values which are not strings are JSON encoded.",
+				ElementType:   types.StringType,
+				Computed:      true,
+				PlanModifiers: []planmodifier.Map{mapplanmodifier.UseStateForUnknown()},
+			},
+
+			"plan_event": schema.BoolAttribute{
+				Description: "Call the script with the 'plan' event to predict the result and find changes requiring replacement.",
+				Optional:    true,
+			},
+
+			"deletion_protection": schema.BoolAttribute{
+				Description: "Fail any plan or apply which would destroy or replace the resource while this is true.",
+				Optional:    true,
+			},
+
+			"on_destroy": schema.StringAttribute{
+				Description: "What destroying the resource does: 'delete' runs the 'delete' event, 'abandon' only removes it from the state.",
+				Optional:    true,
+			},
+
+			"idempotency_seed": schema.StringAttribute{
+				Description: "Hashed with the config into the idempotency key passed to 'create'. Set it to tell apart instances with the same config, or change it to get a new key.",
+				Optional:    true,
+			},
+
+			"replace_on_change": schema.ListAttribute{
+				Description: "Keys or JSON pointers into 'config' which cannot be updated in place. A change to any of them replaces the resource.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+
+			"triggers": schema.MapAttribute{
+				Description: "A map of values which replace the resource when any of them change.",
+				ElementType: types.StringType,
+				Optional:    true,
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.RequiresReplace(),
+				},
+			},
+
+			"numeric_coercion": schema.BoolAttribute{
+				Description: "Compare strings holding numbers in 'config' as numbers, e.g. \"20\" and 20 are equal.",
+				Optional:    true,
+			},
+
+			"unordered_keys": schema.ListAttribute{
+				Description: "Names of the keys in 'config' whose arrays are compared as sets, ignoring the order of elements.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+
+			"case_insensitive_keys": schema.ListAttribute{
+				Description: "Names of the keys in 'config' whose string values are compared ignoring case.",
+				ElementType: types.StringType,
+				Optional:    true,
 			},
 		},
 	}
 }
 
-// diffSuppressComputed - Only different if the non @ fields have changed.
-// remove the @ fields from the two JSON strings and then compare them.
-func diffSuppressComputed(_, old, new string, _ *schema.ResourceData) bool {
+// ValidateConfig - 'config' must be an object or a string, neither it nor 'id_key' can be blank
+func (r *universeResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var d resourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &d)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	switch config := d.Config.UnderlyingValue().(type) {
+	case nil, types.Object, types.Map:
+	case types.String:
+		if isWhiteSpace(config) {
+			resp.Diagnostics.AddAttributeError(path.Root("config"), "Invalid config",
+				`expected "config" to not be an empty string or whitespace`)
+		}
+	default:
+		resp.Diagnostics.AddAttributeError(path.Root("config"), "Invalid config",
+			fmt.Sprintf("expected an object or a JSON/YAML/TOML string in \"config\", got %s", config.Type(ctx)))
+	}
+	if isWhiteSpace(d.IDKey) {
+		resp.Diagnostics.AddAttributeError(path.Root("id_key"), "Invalid id_key",
+			`expected "id_key" to not be an empty string or whitespace`)
+	}
+	if !d.ConfigFormat.IsNull() && !d.ConfigFormat.IsUnknown() && !contains(configFormats, d.ConfigFormat.ValueString()) {
+		resp.Diagnostics.AddAttributeError(path.Root("config_format"), "Invalid config_format",
+			fmt.Sprintf("expected config_format to be one of %q, got %s", configFormats, d.ConfigFormat.ValueString()))
+	} else if err := checkConfigFormat(&d); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("config"), "Invalid config", err.Error())
+	}
+	if valid := []string{onDestroyDelete, onDestroyAbandon}; !d.OnDestroy.IsNull() && !d.OnDestroy.IsUnknown() &&
+		!contains(valid, d.OnDestroy.ValueString()) {
+		resp.Diagnostics.AddAttributeError(path.Root("on_destroy"), "Invalid on_destroy",
+			fmt.Sprintf("expected on_destroy to be one of %q, got %s", valid, d.OnDestroy.ValueString()))
+	}
+}
 
-	removeComputed := func(jsonish string) string {
-		var x interface{}
-		jstr, err := decodeConfigToJSON([]byte(jsonish))
-		err = json.Unmarshal(jstr, &x)
+// isWhiteSpace - true if the string is known and blank
+func isWhiteSpace(s types.String) bool {
+	return !s.IsNull() && !s.IsUnknown() && strings.TrimSpace(s.ValueString()) == ""
+}
+
+// ModifyPlan - The script is only run on update, and 'result' and 'outputs' are only unknown, when the
+// script settings or the config change. A config which is the same once normalised is not a change.
+// Protected resources cannot be destroyed or replaced.
+func (r *universeResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	var prior *resourceModel
+	if !req.State.Raw.IsNull() {
+		prior = &resourceModel{}
+		resp.Diagnostics.Append(req.State.Get(ctx, prior)...)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if req.Plan.Raw.IsNull() {
+		if prior != nil && prior.DeletionProtection.ValueBool() {
+			resp.Diagnostics.AddError("Deletion protection", deletionProtectedError(prior.ID.ValueString()).Error())
+		}
+		return // Destroy
+	}
+	var planned, config resourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &planned)...)
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	p, diags := getPrivateState(ctx, req.Private)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if prior != nil {
+		changed, err := hasScriptChange(prior, &planned, req.State.Raw, req.Plan.Raw)
 		if err != nil {
-			log.Printf("diffSuppressComputed():func[removeComputed] Could not parse Interface: %#v ", err)
-			return ""
+			resp.Diagnostics.AddError("Invalid plan", err.Error())
+			return
 		}
-		xmap, ok := x.(map[string]interface{})
-		if !ok {
-			log.Printf("diffSuppressComputed():func[removeComputed] Could not parse Map: %#v ", ok)
-			return ""
+		if !changed {
+			return
 		}
-		for attrName := range xmap {
-			if strings.HasPrefix(attrName, "@") {
-				delete(xmap, attrName)
-			}
+	}
+	// The write-only secrets are only in the configuration, the script may require the 'secrets' feature for them
+	planned.SecretConfig, planned.SecretEnvironment = config.SecretConfig, config.SecretEnvironment
+	replace, err := planChange(prior, &planned, withPrivate(p, "plan", r.providerConfig))
+	planned.SecretConfig, planned.SecretEnvironment = types.DynamicNull(), types.DynamicNull()
+	if err != nil {
+		resp.Diagnostics.AddError("Plan failed", err.Error())
+		return
+	}
+	if replace {
+		resp.RequiresReplace = append(resp.RequiresReplace, path.Root("config"))
+	} else if prior != nil {
+		if capabilities, _ := planCapabilities(&planned, r.providerConfig); capabilities.mutableID() {
+			planned.ID = types.StringUnknown() // Update may return a new id
 		}
-		xbytes, err := json.Marshal(xmap)
+		planConfigVersion(&planned, r.providerConfig)
+	}
+	if prior != nil && prior.DeletionProtection.ValueBool() && len(resp.RequiresReplace) > 0 {
+		resp.Diagnostics.AddError("Deletion protection", deletionProtectedError(prior.ID.ValueString()).Error())
+	}
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, &planned)...)
+}
+
+// hasScriptChange - true if the planned resource differs from the prior state in what the script is given.
+// The deletion policy is not given to the script, and the idempotency seed only to 'create'.
+func hasScriptChange(prior, planned *resourceModel, priorValue, plannedValue tftypes.Value) (bool, error) {
+	changed, err := changedAttributes(priorValue, plannedValue)
+	if err != nil {
+		return false, err
+	}
+	for _, name := range changed {
+		if name != "config" && name != idempotencySeedAttribute && !contains(computedAttributes, name) && !contains(deletionPolicyAttributes, name) {
+			return true, nil
+		}
+	}
+	return configChanged(prior, planned), nil
+}
+
+// changedAttributes - The names of the attributes whose values differ between two values of the resource
+func changedAttributes(prior, planned tftypes.Value) ([]string, error) {
+	priorAttrs, plannedAttrs := map[string]tftypes.Value{}, map[string]tftypes.Value{}
+	if err := prior.As(&priorAttrs); err != nil {
+		return nil, err
+	}
+	if err := planned.As(&plannedAttrs); err != nil {
+		return nil, err
+	}
+	var changed []string
+	for name, v := range plannedAttrs {
+		if !v.Equal(priorAttrs[name]) {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// configChanged - true unless the planned config is the prior config, or the same once both are normalised
+func configChanged(prior, planned *resourceModel) bool {
+	if prior.Config.Equal(planned.Config) {
+		return false
+	}
+	oldJSON, err := getConfigFromTF(prior)
+	if err != nil {
+		return true
+	}
+	newJSON, err := getConfigFromTF(planned)
+	if err != nil {
+		return true // Unknown until apply
+	}
+	return !diffSuppressComputed("config", string(oldJSON), string(newJSON), planned)
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Create - The script gets a new random idempotency key, kept in the private state of the instance
+func (r *universeResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var d, config resourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &d)...)
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	m, err := withSecrets(&config, r.providerConfig)
+	if err != nil {
+		resp.Diagnostics.AddError("Create failed", err.Error())
+		return
+	}
+	key, err := idempotencyKey(r.typeName, &d)
+	if err != nil {
+		resp.Diagnostics.AddError("Create failed", err.Error())
+		return
+	}
+	p := &privateState{}
+	if err = onCreate(&d, key, p, m); err != nil {
+		resp.Diagnostics.AddError("Create failed", err.Error())
+		if d.ID.ValueString() == "" {
+			return
+		}
+		// Partly created, the instance is kept in the state so Terraform taints it
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &d)...)
+	r.setIdentity(resp.Identity, &d, &resp.Diagnostics)
+	resp.Diagnostics.Append(setPrivateState(ctx, resp.Private, p)...)
+}
+
+// Read - The resource is removed from the state when the script reports it no longer exists
+func (r *universeResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var d resourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &d)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	p, diags := getPrivateState(ctx, req.Private)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if err := onRead(&d, p, r.providerConfig); err != nil {
+		resp.Diagnostics.AddError("Read failed", err.Error())
+		return
+	}
+	if d.ID.IsNull() {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &d)...)
+	r.setIdentity(resp.Identity, &d, &resp.Diagnostics)
+	resp.Diagnostics.Append(setPrivateState(ctx, resp.Private, p)...)
+}
+
+// Update - Only the changes planned by ModifyPlan run the script, otherwise the plan is saved as it is
+func (r *universeResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var d, prior, config resourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &d)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &prior)...)
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	p, diags := getPrivateState(ctx, req.Private)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	changed, err := hasScriptChange(&prior, &d, req.State.Raw, req.Plan.Raw)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid plan", err.Error())
+		return
+	}
+	if changed {
+		m, err := withSecrets(&config, r.providerConfig)
 		if err != nil {
-			return ""
+			resp.Diagnostics.AddError("Update failed", err.Error())
+			return
+		}
+		if err = onUpdate(&d, p, m); err != nil {
+			resp.Diagnostics.AddError("Update failed", err.Error())
+			return
+		}
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &d)...)
+	r.setIdentity(resp.Identity, &d, &resp.Diagnostics)
+	resp.Diagnostics.Append(setPrivateState(ctx, resp.Private, p)...)
+}
+
+func (r *universeResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var d resourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &d)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	p, diags := getPrivateState(ctx, req.Private)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if err := onDelete(&d, p, r.providerConfig); err != nil {
+		resp.Diagnostics.AddError("Delete failed", err.Error())
+	}
+}
+
+// ImportState - Import by ID or by identity, running the 'import' event to rebuild the config
+func (r *universeResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	d := nullResourceModel()
+	d.ID = types.StringValue(req.ID)
+	request := parseImportID(req.ID)
+	if req.ID == "" {
+		var err error
+		if request, err = identityImportRequest(&d, req.Identity, r.identityKeys); err != nil {
+			resp.Diagnostics.AddError("Import failed", err.Error())
+			return
 		}
-		return string(xbytes[:])
+	}
+	if err := callImport(&d, r.providerConfig, request); err != nil {
+		resp.Diagnostics.AddError("Import failed", err.Error())
+		return
+	}
+	if d.ID.ValueString() == "" {
+		resp.Diagnostics.AddError("Import failed", "importing by identity needs an 'import' event in the script returning the id")
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &d)...)
+	r.setIdentity(resp.Identity, &d, &resp.Diagnostics)
+}
+
+// resourceModelV1 - The attributes of a resource written by the SDK, with 'config' and 'result' as JSON strings
+type resourceModelV1 struct {
+	ID                  types.String `tfsdk:"id"`
+	Executor            types.String `tfsdk:"executor"`
+	Script              types.String `tfsdk:"script"`
+	Config              types.String `tfsdk:"config"`
+	IDKey               types.String `tfsdk:"id_key"`
+	Result              types.String `tfsdk:"result"`
+	Outputs             types.Map    `tfsdk:"outputs"`
+	NumericCoercion     types.Bool   `tfsdk:"numeric_coercion"`
+	UnorderedKeys       types.List   `tfsdk:"unordered_keys"`
+	CaseInsensitiveKeys types.List   `tfsdk:"case_insensitive_keys"`
+}
+
+// resourceSchemaV1 - The schema written by the SDK
+func resourceSchemaV1() schema.Schema {
+	stringList := schema.ListAttribute{ElementType: types.StringType, Optional: true}
+	return schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"id":                    schema.StringAttribute{Computed: true},
+			"executor":              schema.StringAttribute{Optional: true},
+			"script":                schema.StringAttribute{Optional: true},
+			"config":                schema.StringAttribute{Required: true},
+			"id_key":                schema.StringAttribute{Optional: true},
+			"result":                schema.StringAttribute{Computed: true},
+			"outputs":               schema.MapAttribute{ElementType: types.StringType, Computed: true},
+			"numeric_coercion":      schema.BoolAttribute{Optional: true},
+			"unordered_keys":        stringList,
+			"case_insensitive_keys": stringList,
+		},
+	}
+}
+
+// UpgradeState - Version 1 was written by the SDK, see upgradeStateV1
+func (r *universeResource) UpgradeState(_ context.Context) map[int64]resource.StateUpgrader {
+	priorSchema := resourceSchemaV1()
+	return map[int64]resource.StateUpgrader{
+		1: {PriorSchema: &priorSchema, StateUpgrader: upgradeStateV1},
+	}
+}
+
+// upgradeStateV1 - 'config' stays a string and the JSON in 'result' is decoded. Before 'result' existed the
+// script response was written into 'config', so it is copied to 'result' and 'outputs'.
+func upgradeStateV1(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+	var prior resourceModelV1
+	resp.Diagnostics.Append(req.State.Get(ctx, &prior)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	d := nullResourceModel()
+	d.ID = prior.ID
+	d.Executor = prior.Executor
+	d.Script = prior.Script
+	d.Config = types.DynamicValue(prior.Config)
+	d.IDKey = prior.IDKey
+	d.Outputs = prior.Outputs
+	d.NumericCoercion = prior.NumericCoercion
+	d.UnorderedKeys = prior.UnorderedKeys
+	d.CaseInsensitiveKeys = prior.CaseInsensitiveKeys
+	response := prior.Result
+	if response.IsNull() {
+		response = prior.Config
+	}
+	responseMap := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(response.ValueString()), &responseMap); err != nil {
+		log.Printf("upgradeStateV1() the response is not a JSON object, leaving result empty: %#v", err)
+	} else if err = setResult(&d, responseMap); err != nil {
+		resp.Diagnostics.AddError("Invalid state", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &d)...)
+}
+
+// diffSuppressComputed - Only different if the non @ fields have changed.
+// Both configs are decoded (JSON, YAML or TOML), the @ fields removed and the
+// normalisation rules of the resource applied before they are compared.
+func diffSuppressComputed(_, old, new string, d *resourceModel) bool {
+	rules := normalizeRules{}
+	if d != nil {
+		rules = getNormalizeRules(d)
 	}
 
-	newJSON := removeComputed(new)
-	oldJSON := removeComputed(old)
+	if old == new {
+		return true
+	}
+	// A config which cannot be normalised is never suppressed, the plan fails on it in checkNormalize
+	newJSON, err := normalizeConfig(new, rules)
+	if err != nil {
+		log.Printf("diffSuppressComputed() Could not normalize the new config: %#v ", err)
+		return false
+	}
+	oldJSON, err := normalizeConfig(old, rules)
+	if err != nil {
+		log.Printf("diffSuppressComputed() Could not normalize the old config: %#v ", err)
+		return false
+	}
 
 	result := newJSON == oldJSON
 	log.Printf("diffSuppressComputed() %#v for\n* %#v\n* %#v \n", result, old, new)
@@ -98,31 +718,55 @@ func diffSuppressComputed(_, old, new string, _ *schema.ResourceData) bool {
 	return result
 }
 
-func onCreate(d *schema.ResourceData, m interface{}) error {
-	_, err := callExecutor("create", d, m)
+// onCreate - The script gets the idempotency key of the instance, so a retried create does not make a duplicate
+func onCreate(d *resourceModel, key string, p *privateState, m interface{}) error {
+	m = withEnvironment(m, map[string]string{idempotencyKeyVar: key})
+	_, err := callExecutor("create", d, withPrivate(p, "create", m))
 	return err
 }
 
-func onRead(d *schema.ResourceData, m interface{}) error {
-	_, err := callExecutor("read", d, m)
+// onRead - Check the object still exists, then upgrade the stored config first when the script declares a
+// new config version. The id is cleared when the object is gone.
+func onRead(d *resourceModel, p *privateState, m interface{}) error {
+	exists, err := onExists(d, withPrivate(p, "exists", m))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		d.ID = types.StringNull()
+		return nil
+	}
+	if err = upgradeConfig(d, withPrivate(p, "upgrade", m)); err != nil {
+		return err
+	}
+	_, err = callExecutor("read", d, withPrivate(p, "read", m))
 	return err
 }
 
-func onUpdate(d *schema.ResourceData, m interface{}) error {
-	_, err := callExecutor("update", d, m)
+func onUpdate(d *resourceModel, p *privateState, m interface{}) error {
+	_, err := callExecutor("update", d, withPrivate(p, "update", m))
 	return err
 }
 
-func onDelete(d *schema.ResourceData, m interface{}) error {
-	_, err := callExecutor("delete", d, m)
+// onDelete - Protected resources fail, abandoned resources are removed from the state without running the script
+func onDelete(d *resourceModel, p *privateState, m interface{}) error {
+	run, err := checkDeletionPolicy(d)
+	if err != nil || !run {
+		return err
+	}
+	_, err = callExecutor("delete", d, withPrivate(p, "delete", m))
 	return err
 }
 
-func onExists(d *schema.ResourceData, m interface{}) (bool, error) {
+func onExists(d *resourceModel, m interface{}) (bool, error) {
+	effectiveDefaults, _, err := extractEssentialFields("exists", d.settings(), m)
+	if err == nil && !getCapabilities(effectiveDefaults).supportsEvent("exists") {
+		return true, nil // Read will find out
+	}
 	return callExecutor("exists", d, m)
 }
 
-func getFromDefaultsOrResource(name string, defaults map[string]interface{}, d ResourceLike, required bool) (string, bool) {
+func getFromDefaultsOrResource(name string, defaults map[string]interface{}, d ResourceGetter, required bool) (string, bool) {
 	//
 	log.Printf("getFromDefaultsOrResource() field %s in %#v or %#v\n", name, defaults, required)
 
@@ -147,10 +791,10 @@ func getFromDefaultsOrResource(name string, defaults map[string]interface{}, d R
 }
 
 // callExecutor - function to handle all the CRUDE. Returns with bool for 'exit'  all other responses
-// are made in updates of the schema.ResourceData.
-func callExecutor(event string, d ResourceLike, providerConfig interface{}) (bool, error) {
+// are made in updates of the resource model.
+func callExecutor(event string, d *resourceModel, providerConfig interface{}) (bool, error) {
 
-	effectiveDefaults, id, err := extractEssentialFields(event, d, providerConfig)
+	effectiveDefaults, id, err := extractEssentialFields(event, d.settings(), providerConfig)
 	if err != nil {
 		return false, err
 	}
@@ -163,25 +807,14 @@ func callExecutor(event string, d ResourceLike, providerConfig interface{}) (boo
 	}
 	log.Printf("Executing: %s", string(configData))
 
-	pwd, _ := os.Getwd()
-	scriptPath, err := filepath.Abs(pwd + "/" + effectiveDefaults["script"].(string))
-	if err != nil {
-		return false, err
-	}
-
-	cmd := exec.Command(effectiveDefaults["executor"].(string), scriptPath, event)
-	cmd.Env = makeEnvironment(id, effectiveDefaults)
-
-	if event == "delete" {
-		cmd.Stdin = bytes.NewReader([]byte{})
-	} else {
-		cmd.Stdin = bytes.NewReader(configData)
+	// Delete gets the config from the state so the script knows what to clean up, unless the provider asks for the old empty stdin
+	if emptyStdin, _ := effectiveDefaults["delete_empty_stdin"].(bool); event == "delete" && emptyStdin {
+		configData = []byte{}
 	}
-	// Call the executor
-	rawResponse, err := cmd.Output()
+	rawResponse, err := runScript(event, id, effectiveDefaults, configData)
 	if err != nil {
-		if ee, ok := err.(*exec.ExitError); ok {
-			return false, fmt.Errorf("command error: %s", string(ee.Stderr))
+		if event == "create" {
+			return false, partialCreate(d, rawResponse, effectiveDefaults, err)
 		}
 		return false, err
 	}
@@ -198,41 +831,257 @@ func callExecutor(event string, d ResourceLike, providerConfig interface{}) (boo
 		}
 		return exists, nil
 	} else if event == "delete" {
-		d.SetId("")
+		d.ID = types.StringNull()
 	} else {
 		responseMap, ok := response.(map[string]interface{})
 		if !ok {
 			return false, fmt.Errorf("expecting map[string]interface{} from subprocess, got '%#v'", string(rawResponse))
 		}
-		// Get the id_key field from the response and move it into the special id member in the resourceData
-		idKey := effectiveDefaults["id_key"].(string)
+		scriptErr := takeScriptError(event, responseMap)
+		if err = takePrivate(responseMap, effectiveDefaults); err != nil {
+			return false, err
+		}
+		// Get the id_key fields from the response and copy the id into the special id member in the resourceData.
+		// Read, and update of a script with the 'mutable_id' feature, may return a new id when the object has been re-keyed.
+		newID, err := idFromResponse(responseMap, effectiveDefaults)
 		if event == "create" {
-			idRaw, ok := responseMap[idKey]
-			if !ok {
-				return false, fmt.Errorf("missing id attribute '%s' in response: %s", idKey, string(rawResponse))
+			if err != nil {
+				if scriptErr != nil {
+					return false, scriptErr
+				}
+				return false, fmt.Errorf("%w: %s", err, redactResponse(rawResponse, sensitiveKeys(d.settings())))
 			}
-			id, ok := idRaw.(string)
-			if !ok {
-				return false, fmt.Errorf("expected string in id attribute '%s' in response but got: %#v", idKey, idRaw)
+			d.ID = types.StringValue(newID)
+		} else if err == nil && newID != id {
+			if event == "update" && !getCapabilities(effectiveDefaults).mutableID() {
+				log.Printf("callExecutor() update returned the id '%s' but the script has no '%s' feature, keeping '%s'", newID, mutableIDFeature, id)
+			} else {
+				log.Printf("callExecutor() %s changed the id from '%s' to '%s'", event, id, newID)
+				d.ID = types.StringValue(newID)
 			}
-			d.SetId(id)
 		}
-		delete(responseMap, idKey)
 
-		// Now set the payload in the resource data 'config' field
-		payloadBytes, err := json.Marshal(responseMap)
+		// The response goes in the computed 'result' and 'outputs', 'config' stays as the user wrote it
+		err = setResult(d, responseMap)
 		if err != nil {
 			return false, err
 		}
-		err = d.Set("config", string(payloadBytes))
+		if event != "read" {
+			setConfigVersion(d, effectiveDefaults)
+		} else {
+			err = refreshConfig(d, responseMap)
+			if err != nil {
+				return false, err
+			}
+		}
+		if scriptErr != nil {
+			return false, scriptErr
+		}
+	}
+
+	return false, err
+}
+
+// takeScriptError - Remove the '_error' field from the response. A script sets it when the event failed part way,
+// e.g. the object was created but a later step failed, so the rest of the response is still saved.
+func takeScriptError(event string, responseMap map[string]interface{}) error {
+	v, ok := responseMap["_error"]
+	if !ok {
+		return nil
+	}
+	delete(responseMap, "_error")
+	message, ok := v.(string)
+	if !ok {
+		b, _ := json.Marshal(v)
+		message = string(b)
+	}
+	return fmt.Errorf("%s failed: %s", event, message)
+}
+
+// partialCreate - A failed create still sets the id when the script printed an object holding it, so
+// Terraform keeps the object in the state as tainted and replaces it on the next apply instead of orphaning it.
+func partialCreate(d *resourceModel, rawResponse []byte, effectiveDefaults map[string]interface{}, scriptErr error) error {
+	response, err := jsonSafeUnmarshal(rawResponse, nil)
+	if err != nil {
+		return scriptErr
+	}
+	responseMap, ok := response.(map[string]interface{})
+	if !ok {
+		return scriptErr
+	}
+	if e := takeScriptError("create", responseMap); e != nil {
+		scriptErr = fmt.Errorf("%w: %s", scriptErr, e.Error())
+	}
+	if err = takePrivate(responseMap, effectiveDefaults); err != nil {
+		return err
+	}
+	id, err := idFromResponse(responseMap, effectiveDefaults)
+	if err != nil {
+		return scriptErr
+	}
+	log.Printf("partialCreate() keeping the id '%s' of the partly created object", id)
+	d.ID = types.StringValue(id)
+	if err = setResult(d, responseMap); err != nil {
+		return err
+	}
+	setConfigVersion(d, effectiveDefaults)
+	return scriptErr
+}
+
+// setResult - Store the script response in the computed 'result' object and the flat 'outputs' map.
+// Sensitive fields are removed from the response and stored in 'sensitive_result' instead.
+func setResult(d *resourceModel, responseMap map[string]interface{}) error {
+	sensitive, err := jsonToDynamic(splitSensitive(responseMap, sensitiveKeys(d.settings())))
+	if err != nil {
+		return err
+	}
+	d.SensitiveResult = sensitive
+	result, err := jsonToDynamic(responseMap)
+	if err != nil {
+		return err
+	}
+	d.Result = result
+	log.Printf("Executed: setting result to: %s", result)
+
+	outputs, err := flattenOutputs(responseMap)
+	if err != nil {
+		return err
+	}
+	d.Outputs, err = stringMapValue(outputs)
+	return err
+}
+
+// stringMapValue - The map of strings returned by flattenOutputs as a Terraform map. The unknown marker
+// becomes an unknown value.
+func stringMapValue(m map[string]interface{}) (types.Map, error) {
+	elements := make(map[string]attr.Value, len(m))
+	for k, v := range m {
+		s, ok := v.(string)
+		if !ok {
+			return types.MapNull(types.StringType), fmt.Errorf("expected string in '%s', but got: %#v", k, v)
+		}
+		if s == unknownVariableValue {
+			elements[k] = types.StringUnknown()
+			continue
+		}
+		elements[k] = types.StringValue(s)
+	}
+	value, diags := types.MapValue(types.StringType, elements)
+	return value, diagsError(diags)
+}
+
+// diagsError - The first error in the diagnostics of the framework, or nil
+func diagsError(diags diag.Diagnostics) error {
+	for _, d := range diags.Errors() {
+		return fmt.Errorf("%s: %s", d.Summary(), d.Detail())
+	}
+	return nil
+}
+
+// flattenOutputs - Convert the top level of the response into a map of strings. Strings are
+// copied as they are, anything else is converted to JSON.
+func flattenOutputs(responseMap map[string]interface{}) (map[string]interface{}, error) {
+	outputs := make(map[string]interface{}, len(responseMap))
+	for k, v := range responseMap {
+		switch value := v.(type) {
+		case nil:
+			continue
+		case string:
+			outputs[k] = value
+		default:
+			b, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			outputs[k] = string(b)
+		}
+	}
+	return outputs, nil
+}
+
+// refreshConfig - Detect drift in the keys the user has put in 'config'. Only when the script returns
+// different values for them is 'config' rewritten, so a plan will put them back. A config written as a string
+// stays a string.
+func refreshConfig(d *resourceModel, responseMap map[string]interface{}) error {
+	configData, err := getConfigFromTF(d)
+	if err != nil {
+		return err
+	}
+	config := map[string]interface{}{}
+	err = json.Unmarshal(configData, &config)
+	if err != nil {
+		return err
+	}
+	refreshed := make(map[string]interface{}, len(config))
+	for k, v := range config {
+		if strings.HasPrefix(k, "@") {
+			refreshed[k] = v
+			continue
+		}
+		if rv, ok := responseMap[k]; ok {
+			refreshed[k] = rv
+		}
+	}
+	refreshedBytes, err := json.Marshal(refreshed)
+	if err != nil {
+		return err
+	}
+	rules := getNormalizeRules(d)
+	oldJSON, err := normalizeConfig(string(configData), rules)
+	if err != nil {
+		return err
+	}
+	newJSON, err := normalizeConfig(string(refreshedBytes), rules)
+	if err != nil {
+		return err
+	}
+	if oldJSON == newJSON {
+		return nil
+	}
+	log.Printf("refreshConfig() drift detected, setting config to: %s", string(refreshedBytes))
+	return setConfig(d, refreshed)
+}
+
+// setConfig - Replace 'config' in the state. A config written as a string stays a string.
+func setConfig(d *resourceModel, config map[string]interface{}) error {
+	if _, ok := d.Config.UnderlyingValue().(types.String); ok {
+		b, err := json.Marshal(config)
 		if err != nil {
-			return false, err
+			return err
 		}
+		d.Config = types.DynamicValue(types.StringValue(string(b)))
+		return nil
+	}
+	var err error
+	d.Config, err = jsonToDynamic(config)
+	return err
+}
+
+// runScript - Run the script with the executor for the event, writing stdin to the script and returning its stdout.
+// When the script fails, whatever it printed on stdout is returned with the error.
+func runScript(event string, id string, effectiveDefaults map[string]interface{}, stdin []byte) ([]byte, error) {
+	pwd, _ := os.Getwd()
+	scriptPath, err := filepath.Abs(pwd + "/" + effectiveDefaults["script"].(string))
+	if err != nil {
+		return nil, err
+	}
 
-		log.Printf("Executed: setting data to: %s", string(payloadBytes))
+	cmd := exec.Command(scriptPath, event) // Without an executor the script runs itself, e.g. with a #! line
+	if executor, _ := effectiveDefaults["executor"].(string); executor != "" {
+		cmd = exec.Command(executor, scriptPath, event)
 	}
+	cmd.Env = makeEnvironment(id, effectiveDefaults)
+	cmd.Stdin = bytes.NewReader(stdin)
 
-	return false, err
+	// Call the executor
+	rawResponse, err := cmd.Output()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok {
+			return rawResponse, fmt.Errorf("command error: %s", string(ee.Stderr))
+		}
+		return nil, err
+	}
+	return rawResponse, nil
 }
 
 // jsonSafeUnmarshal - copes with empty input
@@ -241,7 +1090,7 @@ func jsonSafeUnmarshal(result []byte, err error) (interface{}, error) {
 	if len(result) == 0 {
 		resource = nil
 	} else {
-		err = json.Unmarshal(result, &resource)
+		err = unmarshalWithNumbers(result, &resource)
 		if err != nil {
 			return nil, err
 		}
@@ -249,11 +1098,24 @@ func jsonSafeUnmarshal(result []byte, err error) (interface{}, error) {
 	return resource, err
 }
 
-// makeEnvironment - Add the id and the 'environment' to the parent process environment,
-// returning []string
+// unmarshalWithNumbers - json.Unmarshal keeping numbers as json.Number, so large numeric ids are exact
+func unmarshalWithNumbers(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return fmt.Errorf("unexpected data after JSON: %s", string(data))
+	}
+	return nil
+}
+
+// makeEnvironment - Add the id, the 'environment' and then the secret environment, which is not logged,
+// to the parent process environment, returning []string
 func makeEnvironment(id string, effectiveDefaults map[string]interface{}) []string {
 	environ := os.Environ()
-	environ = append(environ, fmt.Sprintf("%s=%s", effectiveDefaults["id_key"], id))
+	environ = append(environ, idEnvironment(id, effectiveDefaults)...)
 	for k, v := range effectiveDefaults {
 		if s, ok := v.(string); ok {
 			e := fmt.Sprintf("%s=%s", k, s)
@@ -270,41 +1132,48 @@ func makeEnvironment(id string, effectiveDefaults map[string]interface{}) []stri
 			}
 		}
 	}
+	if secrets, ok := effectiveDefaults[secretEnvironmentField].(secretEnvironment); ok {
+		environ = append(environ, secrets.environ()...)
+	}
 	return environ
 }
 
-// extractEssentialFields - get the important fields from the provider config or resourceData.
+// extractEssentialFields - get the important fields from the provider config or the settings of the resource.
 // returning the a map[string] of the fields and the id field
-func extractEssentialFields(event string, d ResourceLike, providerConfig interface{}) (map[string]interface{}, string, error) {
+func extractEssentialFields(event string, d ResourceGetter, providerConfig interface{}) (map[string]interface{}, string, error) {
 	essentialFields := map[string]bool{
 		// map[field name]mandatory?
-		"environment": false,
-		"executor":    true,
-		"id_key":      true,
-		"script":      true,
+		"environment":  false,
+		"executor":     true,
+		"id_key":       true,
+		"id_separator": false,
+		"script":       true,
 	}
-	stringFields := []string{"id_key", "executor", "script"}
+	stringFields := []string{"id_key", "id_separator", "executor", "script"}
 
 	var effectiveDefaults = map[string]interface{}{}
 
 	id := d.Id()
 	log.Printf("callExecutor() '%s' %s %#v", id, event, providerConfig)
 	for n := range essentialFields {
-		log.Printf("callExecutor() ResourceData field %s = %#v", n, d.Get(n))
+		log.Printf("callExecutor() resource field %s = %#v", n, d.Get(n))
 	}
 	// Validate provider configuration
 	if providerConfig != nil {
-		var ok bool
-		effectiveDefaults, ok = providerConfig.(map[string]interface{})
+		defaults, ok := providerConfig.(map[string]interface{})
 		if !ok {
 			return nil, "", fmt.Errorf("was expecting map[string]interface{} in provider configuration, got %#v", providerConfig)
 		}
+		// Copy, so the fields of one resource do not become the defaults of the next
+		for k, v := range defaults {
+			effectiveDefaults[k] = v
+		}
 	}
 	// Extract essential fields from provider configuration or resource data
 	for k, required := range essentialFields {
 		value, found := getFromDefaultsOrResource(k, effectiveDefaults, d, required)
 		if (!found) && required {
-			return effectiveDefaults, id, fmt.Errorf("missing required field %s in %v or %#v", k, effectiveDefaults, d)
+			return effectiveDefaults, id, fmt.Errorf("missing required field %s", k)
 		}
 		if !found {
 			continue
@@ -323,19 +1192,27 @@ func extractEssentialFields(event string, d ResourceLike, providerConfig interfa
 	return effectiveDefaults, id, nil
 }
 
-// getConfigFromTF - Validate and extract the 'config' JSON field from the resourceData, returning []byte
-func getConfigFromTF(d ResourceLike) ([]byte, error) {
-	dr, ok := d.GetOk("config")
-	if !ok || dr == nil {
+// getConfigFromTF - Validate and extract the 'config' object, or decode the string, returning JSON []byte
+func getConfigFromTF(d *resourceModel) ([]byte, error) {
+	if d.Config.IsNull() || d.Config.IsUnderlyingValueNull() {
 		return nil, fmt.Errorf("missing 'config'")
 	}
-	js, ok := dr.(string)
-	if !ok {
-		return nil, fmt.Errorf("expected string in 'config', but got: %#v", dr)
-	}
-	db := []byte(js)
+	return configJSON(d.Config, getConfigFormat(d))
+}
 
-	return decodeConfigToJSON(db)
+// configJSON - A 'config' object, or a string in the format, as JSON
+func configJSON(value types.Dynamic, format string) ([]byte, error) {
+	if js, ok := value.UnderlyingValue().(types.String); ok {
+		return decodeConfigFormat([]byte(js.ValueString()), format)
+	}
+	config, err := dynamicToJSON(value)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := config.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("expected an object in 'config', but got: %#v", config)
+	}
+	return json.Marshal(config)
 }
 
 func decodeConfigToJSON(str []byte) ([]byte, error) {
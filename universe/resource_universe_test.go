@@ -1,10 +1,52 @@
 package universe
 
 import (
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/structure"
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"reflect"
+	"strings"
 	"testing"
 )
 
+// normalizeJSONString - Re-encode a JSON string so equal JSON compares equal
+func normalizeJSONString(v interface{}) (string, error) {
+	s, _ := v.(string)
+	var x interface{}
+	if err := json.Unmarshal([]byte(s), &x); err != nil {
+		return s, err
+	}
+	b, err := json.Marshal(x)
+	return string(b), err
+}
+
+// testResource - A resource with the config written as a string, and the computed attributes unknown as in a plan
+func testResource(id string, config string) *resourceModel {
+	d := &resourceModel{
+		ID:      types.StringNull(),
+		Config:  types.DynamicValue(types.StringValue(config)),
+		Result:  types.DynamicUnknown(),
+		Outputs: types.MapUnknown(types.StringType),
+	}
+	if id != "" {
+		d.ID = types.StringValue(id)
+	}
+	return d
+}
+
+// testJSON - A dynamic attribute as a JSON string
+func testJSON(t *testing.T, v types.Dynamic) string {
+	if s, ok := v.UnderlyingValue().(types.String); ok {
+		return s.ValueString()
+	}
+	x, err := dynamicToJSON(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := json.Marshal(x)
+	return string(b)
+}
+
 // (name string, defaults map[string]interface{}, d ResourceLike, required bool
 
 func Test_getFromDefaultsOrResource(t *testing.T) {
@@ -27,8 +69,7 @@ func Test_getFromDefaultsOrResource(t *testing.T) {
 }
 
 func Test_callExecutorCreate(t *testing.T) {
-	d := NewMockResource()
-	_ = d.Set("config", `{"album": "white"}`)
+	d := testResource("", `{"album": "white"}`)
 	config := map[string]interface{}{
 		"id_key":   "id",
 		"executor": "python3",
@@ -38,20 +79,46 @@ func Test_callExecutorCreate(t *testing.T) {
 	if err != nil {
 		t.FailNow()
 	}
-	if d.Id() != "42" {
+	if d.ID.ValueString() != "42" {
 		t.Fail()
 	}
-	c := d.Get("config")
-	n1, _ := structure.NormalizeJsonString(c)
-	n2, _ := structure.NormalizeJsonString(`{"@created":"26/10/2020 18:55:51", "album":"white"}`)
+	if testJSON(t, d.Config) != `{"album": "white"}` {
+		t.Fail()
+	}
+	r := testJSON(t, d.Result)
+	n1, _ := normalizeJSONString(r)
+	n2, _ := normalizeJSONString(`{"@created":"26/10/2020 18:55:51", "album":"white", "id":"42"}`)
 	if n1 != n2 {
 		t.Fail()
 	}
+	outputs := d.Outputs.Elements()
+	if !outputs["album"].Equal(types.StringValue("white")) || !outputs["id"].Equal(types.StringValue("42")) {
+		t.Fail()
+	}
+}
+func Test_callExecutorPartialCreate(t *testing.T) {
+	config := map[string]interface{}{
+		"id_key":   "id",
+		"executor": "python3",
+		"script":   "resource_universe_test.py",
+	}
+	for fail, id := range map[string]string{"error": "44", "exit": "45"} {
+		d := testResource("", fmt.Sprintf(`{"fail": "%s"}`, fail))
+		_, err := callExecutor("create", d, config)
+		if err == nil || !strings.Contains(err.Error(), "tagging failed") {
+			t.Errorf("expected the error of the script for %s but got %#v", fail, err)
+		}
+		if d.ID.ValueString() != id {
+			t.Errorf("expected the id %s to be kept but got '%s'", id, d.ID.ValueString())
+		}
+		if r := testJSON(t, d.Result); strings.Contains(r, "_error") {
+			t.Errorf("expected no _error in the result %s", r)
+		}
+	}
 }
+
 func Test_callExecutorUpdate(t *testing.T) {
-	d := NewMockResource()
-	d.SetId("42")
-	_ = d.Set("config", `{"album": "black"}`)
+	d := testResource("42", `{"album": "black"}`)
 	config := map[string]interface{}{
 		"id_key":   "id",
 		"executor": "python3",
@@ -61,23 +128,101 @@ func Test_callExecutorUpdate(t *testing.T) {
 	if err != nil {
 		t.FailNow()
 	}
-	c := d.Get("config")
-	n1, _ := structure.NormalizeJsonString(c)
-	n2, _ := structure.NormalizeJsonString(`{"@created":"26/10/2020 18:55:51", "album":"black"}`)
+	r := testJSON(t, d.Result)
+	n1, _ := normalizeJSONString(r)
+	n2, _ := normalizeJSONString(`{"@created":"26/10/2020 18:55:51", "album":"black", "id":"42"}`)
 	if n1 != n2 {
 		t.Fail()
 	}
 }
 
+func Test_callExecutorUpdateNewID(t *testing.T) {
+	d := testResource("41", `{"album": "black"}`)
+	config := map[string]interface{}{
+		"id_key":   "id",
+		"executor": "python3",
+		"script":   "resource_universe_test.py",
+	}
+	_, err := callExecutor("update", d, config)
+	if err != nil {
+		t.FailNow()
+	}
+	if d.ID.ValueString() != "41" {
+		t.Errorf("expected the id kept without the mutable_id feature but got %s", d.ID.ValueString())
+	}
+	config["script"] = "mutable_id_test.py"
+	if _, err = callExecutor("update", d, config); err != nil {
+		t.FailNow()
+	}
+	if d.ID.ValueString() != "43" {
+		t.Errorf("expected the new id but got %s", d.ID.ValueString())
+	}
+}
+
+func Test_callExecutorRead(t *testing.T) {
+	d := testResource("42", "album: white\n")
+	config := map[string]interface{}{
+		"id_key":   "id",
+		"executor": "python3",
+		"script":   "resource_universe_test.py",
+	}
+	_, err := callExecutor("read", d, config)
+	if err != nil {
+		t.FailNow()
+	}
+	if testJSON(t, d.Config) != "album: white\n" {
+		t.Fail()
+	}
+	// The script reports a different album for id 43, which must show as drift in config
+	d.ID = types.StringValue("43")
+	_, err = callExecutor("read", d, config)
+	if err != nil {
+		t.FailNow()
+	}
+	n1, _ := normalizeJSONString(testJSON(t, d.Config))
+	n2, _ := normalizeJSONString(`{"album":"abbey road"}`)
+	if n1 != n2 {
+		t.Fail()
+	}
+	// A config written as an object stays an object
+	d.Config, _ = jsonToDynamic(map[string]interface{}{"album": "white"})
+	_, err = callExecutor("read", d, config)
+	if err != nil {
+		t.FailNow()
+	}
+	if _, ok := d.Config.UnderlyingValue().(types.Object); !ok || testJSON(t, d.Config) != `{"album":"abbey road"}` {
+		t.Errorf("expected the drift in an object but got %s", d.Config)
+	}
+}
+
+func Test_flattenOutputs(t *testing.T) {
+	outputs, err := flattenOutputs(map[string]interface{}{
+		"arn":   "arn:aws:x",
+		"count": 3.0,
+		"on":    true,
+		"tags":  []interface{}{"a"},
+		"none":  nil,
+	})
+	if err != nil {
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(outputs, map[string]interface{}{
+		"arn":   "arn:aws:x",
+		"count": "3",
+		"on":    "true",
+		"tags":  `["a"]`,
+	}) {
+		t.Errorf("unexpected outputs %#v", outputs)
+	}
+}
+
 func Test_callExecutorExists(t *testing.T) {
-	d := NewMockResource()
-	_ = d.Set("config", `{"album": "white"}`)
+	d := testResource("42", `{"album": "white"}`)
 	config := map[string]interface{}{
 		"id_key":   "id",
 		"executor": "python3",
 		"script":   "resource_universe_test.py",
 	}
-	d.SetId("42")
 	exists, err := callExecutor("exists", d, config)
 	if !exists || err != nil {
 		t.Fail()
@@ -85,9 +230,7 @@ func Test_callExecutorExists(t *testing.T) {
 }
 
 func Test_callExecutorDelete(t *testing.T) {
-	d := NewMockResource()
-	d.SetId("42")
-	_ = d.Set("config", `{"album": "white"}`)
+	d := testResource("42", `{"album": "white"}`)
 	config := map[string]interface{}{
 		"id_key":   "id",
 		"executor": "python3",
@@ -97,11 +240,28 @@ func Test_callExecutorDelete(t *testing.T) {
 	if err != nil {
 		t.FailNow()
 	}
+	if !d.ID.IsNull() {
+		t.Errorf("expected the id to be cleared but got %s", d.ID)
+	}
+}
+
+func Test_callExecutorDeleteEmptyStdin(t *testing.T) {
+	d := testResource("42", `{"album": "white"}`)
+	config := map[string]interface{}{
+		"id_key":             "id",
+		"executor":           "python3",
+		"script":             "resource_universe_test.py",
+		"delete_empty_stdin": true,
+		"environment":        map[string]interface{}{"expect_empty_stdin": "1"},
+	}
+	_, err := callExecutor("delete", d, config)
+	if err != nil {
+		t.Errorf("expected nothing on stdin for delete %#v", err)
+	}
 }
 
 func Test_callExecutorBad(t *testing.T) {
-	d := NewMockResource()
-	_ = d.Set("config", `{"album": "white"}`)
+	d := testResource("", `{"album": "white"}`)
 	config := map[string]interface{}{
 		"id_key":   "id",
 		"executor": "", // Bad or wrong path to program
@@ -8,16 +8,100 @@ if __name__ == '__main__':
     ident = os.environ.get("id")  # Get the id if present else None
 
     if event == "delete":
-        exit(0)
+        # The config is on stdin unless the provider sets delete_empty_stdin
+        entre = sys.stdin.read()
+        if os.environ.get("expect_empty_stdin"):
+            exit(1 if entre else 0)
+        exit(0 if "album" in json.loads(entre) else 1)
 
     # Read the JSON from standard input
     entre = sys.stdin.read()
     input_dict = json.loads(entre)
 
+    if event == "function":
+        if "counter" in input_dict:
+            with open(input_dict["counter"], "a") as f:
+                f.write(os.environ["function"] + "\n")
+        print(json.dumps({"upper": input_dict["name"].upper()}))
+        exit(0)
+
+    if event == "open":
+        print(json.dumps({"id": "e1", "token": "t-" + input_dict["name"], "_private": {"lease": "l1"},
+                          "_renew_at": "2030-01-01T00:00:00Z"}))
+        exit(0)
+
+    if event == "renew":
+        lease = json.loads(os.environ["UNIVERSE_PRIVATE"])["lease"]
+        print(json.dumps({"_private": {"lease": lease + "-renewed"}}))
+        exit(0)
+
+    if event == "close":
+        # Revoke the lease of the token
+        exit(0 if ident == "e1" and "lease" in json.loads(os.environ["UNIVERSE_PRIVATE"]) else 1)
+
+    if event == "plan":
+        result = dict(input_dict["proposed"], id="42")
+        if "UNIVERSE_PRIVATE" in os.environ:
+            result["@handle"] = json.loads(os.environ["UNIVERSE_PRIVATE"])["handle"]
+        print(json.dumps({"result": result, "unknown": ["@created"], "requires_replace": ["album"]}))
+        exit(0)
+
+    if event == "list":
+        print(json.dumps([
+            {"id": "42", "config": {"album": "white"}},
+            {"id": "43", "album": "abbey road", "@created": "26/10/2020 18:55:51"},
+        ]))
+        exit(0)
+
+    if event == "upgrade":
+        # Version 2 renamed 'title' to 'album'
+        config = input_dict["config"]
+        if input_dict["from_version"] < 2 and "title" in config:
+            config["album"] = config.pop("title")
+        if "UNIVERSE_PRIVATE" in os.environ:
+            config["handle"] = json.loads(os.environ["UNIVERSE_PRIVATE"])["handle"]
+        print(json.dumps(config))
+        exit(0)
+
+    if event == "import":
+        print(json.dumps({"id": input_dict["keys"]["id"], "album": "white", "@created": "26/10/2020 18:55:51"}))
+        exit(0)
+
     if event == "exists":
-        print('true' if ident == "42" else 'false')
+        private = json.loads(os.environ.get("UNIVERSE_PRIVATE", "{}"))
+        print('true' if ident == "42" and private.get("handle") != "gone" else 'false')
+        exit(0)
+
+    if event == "read" and ident == "43":
+        input_dict["album"] = "abbey road"
+
+    if event == "create" and "UNIVERSE_IDEMPOTENCY_KEY" in os.environ:
+        input_dict["@idempotency_key"] = os.environ["UNIVERSE_IDEMPOTENCY_KEY"]
+
+    if event == "create" and input_dict.get("fail") == "error":
+        print(json.dumps({"id": "44", "_error": "tagging failed"}))
         exit(0)
 
+    if event == "create" and input_dict.get("fail") == "exit":
+        print(json.dumps({"id": "45"}))
+        sys.stderr.write("tagging failed")
+        exit(1)
+
+    if event == "create" and "password_length" in input_dict:
+        input_dict["!password"] = "x" * input_dict["password_length"]
+        input_dict["token"] = "t0k3n"
+
+    if "UNIVERSE_SECRET_CONFIG" in os.environ:
+        input_dict["@secret_keys"] = sorted(json.loads(os.environ["UNIVERSE_SECRET_CONFIG"]).keys())
+    if "db_password" in os.environ:
+        input_dict["@has_db_password"] = True
+
+    if event == "create" and "remember" in input_dict:
+        input_dict["_private"] = {"handle": input_dict.pop("remember")}
+
+    if "UNIVERSE_PRIVATE" in os.environ:
+        input_dict["@handle"] = json.loads(os.environ["UNIVERSE_PRIVATE"])["handle"]
+
     if event in ["create", "update"]:
         input_dict["@created"] = "26/10/2020 18:55:51"
         input_dict.update({"id": "42"})
@@ -0,0 +1,118 @@
+package universe
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// secretConfigVar - The environment variable holding 'secret_config' as JSON
+const secretConfigVar = "UNIVERSE_SECRET_CONFIG"
+
+// secretEnvironmentField - The field of the effective defaults holding the secretEnvironment of the script
+const secretEnvironmentField = "secret_environment_variables"
+
+// secretEnvironment - Environment variables which must never be logged. They are added to the environment
+// of the script after everything else, and print as redacted when the effective defaults are logged.
+type secretEnvironment map[string]string
+
+func (s secretEnvironment) String() string {
+	return fmt.Sprintf("<%d redacted variables>", len(s))
+}
+
+func (s secretEnvironment) GoString() string {
+	return s.String()
+}
+
+// environ - The variables as KEY=value
+func (s secretEnvironment) environ() []string {
+	environ := make([]string, 0, len(s))
+	for k, v := range s {
+		environ = append(environ, fmt.Sprintf("%s=%s", k, v))
+	}
+	return environ
+}
+
+// withSecretEnvironment - Copy the provider configuration, adding the variables to its secret environment
+func withSecretEnvironment(providerConfig interface{}, vars map[string]string) map[string]interface{} {
+	config := map[string]interface{}{}
+	if defaults, ok := providerConfig.(map[string]interface{}); ok {
+		for k, v := range defaults {
+			config[k] = v
+		}
+	}
+	secrets := secretEnvironment{}
+	if existing, ok := config[secretEnvironmentField].(secretEnvironment); ok {
+		for k, v := range existing {
+			secrets[k] = v
+		}
+	}
+	for k, v := range vars {
+		secrets[k] = v
+	}
+	config[secretEnvironmentField] = secrets
+	return config
+}
+
+// secretVariables - The environment variables for the write-only 'secret_config' and 'secret_environment'
+func secretVariables(secretConfig string, secretEnvironment string) (map[string]string, error) {
+	vars := map[string]string{}
+	if secretConfig != "" {
+		jsonBytes, err := decodeConfigToJSON([]byte(secretConfig))
+		if err != nil {
+			return nil, fmt.Errorf("secret_config: %w", err)
+		}
+		vars[secretConfigVar] = string(jsonBytes)
+	}
+	if secretEnvironment != "" {
+		jsonBytes, err := decodeConfigToJSON([]byte(secretEnvironment))
+		if err != nil {
+			return nil, fmt.Errorf("secret_environment: %w", err)
+		}
+		environment := map[string]interface{}{}
+		if err = unmarshalWithNumbers(jsonBytes, &environment); err != nil {
+			return nil, fmt.Errorf("secret_environment: expected an object of strings: %w", err)
+		}
+		for k, v := range environment {
+			s, err := idString(k, v)
+			if err != nil {
+				return nil, fmt.Errorf("secret_environment: expected a string or number in '%s'", k)
+			}
+			vars[k] = s
+		}
+	}
+	return vars, nil
+}
+
+// secretString - A write-only attribute as the string the user wrote, or an object as JSON. Empty when not set.
+func secretString(v types.Dynamic) (string, error) {
+	if v.IsNull() || v.IsUnknown() || v.IsUnderlyingValueNull() {
+		return "", nil
+	}
+	if s, ok := v.UnderlyingValue().(types.String); ok {
+		return s.ValueString(), nil
+	}
+	x, err := dynamicToJSON(v)
+	if err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(x)
+	return string(b), err
+}
+
+// withSecrets - Add the write-only secrets in the configuration to the environment of the script
+func withSecrets(config *resourceModel, providerConfig interface{}) (interface{}, error) {
+	secretConfig, err := secretString(config.SecretConfig)
+	if err != nil {
+		return nil, fmt.Errorf("secret_config: %w", err)
+	}
+	secretEnvironment, err := secretString(config.SecretEnvironment)
+	if err != nil {
+		return nil, fmt.Errorf("secret_environment: %w", err)
+	}
+	vars, err := secretVariables(secretConfig, secretEnvironment)
+	if err != nil || len(vars) == 0 {
+		return providerConfig, err
+	}
+	return withSecretEnvironment(providerConfig, vars), nil
+}
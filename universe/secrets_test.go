@@ -0,0 +1,128 @@
+package universe
+
+import (
+	"bytes"
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_secretVariables(t *testing.T) {
+	vars, err := secretVariables("api_key: k1\n", `{"db_password": "p", "port": 5432}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{secretConfigVar: `{"api_key":"k1"}`, "db_password": "p", "port": "5432"}
+	if !reflect.DeepEqual(vars, expected) {
+		t.Errorf("unexpected variables %#v", vars)
+	}
+	if _, err = secretVariables("", `{"nested": {"a": 1}}`); err == nil {
+		t.Error("expected an error for an environment variable which is not a string")
+	}
+	if vars, err = secretVariables("", ""); err != nil || len(vars) != 0 {
+		t.Errorf("expected no variables %#v %#v", vars, err)
+	}
+}
+
+func Test_secretsNotLogged(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	config := withSecretEnvironment(map[string]interface{}{
+		"id_key":      "id",
+		"executor":    "python3",
+		"script":      "resource_universe_test.py",
+		"environment": map[string]interface{}{"PLAIN": "visible"},
+	}, map[string]string{secretConfigVar: `{"api_key":"s3cr3t-config"}`, "db_password": "s3cr3t-env"})
+	d := testResource("", `{"album": "white"}`)
+	if _, err := callExecutor("create", d, config); err != nil {
+		t.Fatal(err)
+	}
+	if r := testJSON(t, d.Result); !strings.Contains(r, `"@has_db_password":true`) || !strings.Contains(r, `"@secret_keys":["api_key"]`) {
+		t.Errorf("expected the script to get the secrets but got %s", r)
+	}
+	if !strings.Contains(logs.String(), "PLAIN=visible") {
+		t.Errorf("expected the environment to be logged")
+	}
+	for _, secret := range []string{"s3cr3t-config", "s3cr3t-env"} {
+		if strings.Contains(logs.String(), secret) {
+			t.Errorf("expected %s not to be logged", secret)
+		}
+	}
+}
+
+func Test_secretsNotInErrors(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	d := testResource("", `{"album": "white"}`)
+	d.SecretConfig = types.DynamicValue(types.StringValue(`{"api_key": "SUPERSECRET"}`))
+	config, err := withSecrets(d, map[string]interface{}{
+		"executor": "python3",
+		"script":   "resource_universe_test.py",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// No id_key
+	_, err = callExecutor("create", d, config)
+	if err == nil || err.Error() != "missing required field id_key" {
+		t.Fatalf("expected a missing id_key but got %#v", err)
+	}
+	if strings.Contains(logs.String(), "SUPERSECRET") {
+		t.Error("expected the secret not to be logged")
+	}
+}
+
+func Test_ProviderServerSecrets(t *testing.T) {
+	s := newTestServer(t)
+	testConfigureServer(t, s)
+	objectType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{"db_password": tftypes.String}}
+	secrets := tftypes.NewValue(objectType, map[string]tftypes.Value{"db_password": tftypes.NewValue(tftypes.String, "p")})
+	config := testServerValue(t, s, map[string]tftypes.Value{
+		"config":             testObjectConfig(),
+		"secret_config":      secrets,
+		"secret_environment": secrets,
+	})
+	priorNull, _ := tfprotov5.NewDynamicValue(s.resourceType, tftypes.NewValue(s.resourceType, nil))
+	plan, err := s.PlanResourceChange(context.Background(), &tfprotov5.PlanResourceChangeRequest{
+		TypeName:         DefaultProviderName,
+		PriorState:       &priorNull,
+		ProposedNewState: config,
+		Config:           config,
+	})
+	if err != nil || len(plan.Diagnostics) != 0 {
+		t.Fatalf("plan failed %#v %#v", err, plan.Diagnostics)
+	}
+	apply, err := s.ApplyResourceChange(context.Background(), &tfprotov5.ApplyResourceChangeRequest{
+		TypeName:     DefaultProviderName,
+		PriorState:   &priorNull,
+		PlannedState: plan.PlannedState,
+		Config:       config,
+	})
+	if err != nil || len(apply.Diagnostics) != 0 {
+		t.Fatalf("apply failed %#v %#v", err, apply.Diagnostics)
+	}
+	for name, state := range map[string]*tfprotov5.DynamicValue{"planned": plan.PlannedState, "new": apply.NewState} {
+		value, _ := state.Unmarshal(s.resourceType)
+		attrs := map[string]tftypes.Value{}
+		_ = value.As(&attrs)
+		if !attrs["secret_config"].IsNull() || !attrs["secret_environment"].IsNull() {
+			t.Errorf("expected no secrets in the %s state but got %s", name, value)
+		}
+		if name != "new" {
+			continue
+		}
+		result := map[string]tftypes.Value{}
+		_ = attrs["result"].As(&result)
+		if !result["@has_db_password"].Equal(tftypes.NewValue(tftypes.Bool, true)) || result["@secret_keys"].IsNull() {
+			t.Errorf("expected the script to get the secrets but got %s", attrs["result"])
+		}
+	}
+}
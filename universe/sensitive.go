@@ -0,0 +1,77 @@
+package universe
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+)
+
+// sensitivePrefix - A field of the response named with this prefix is sensitive, e.g. "!password"
+const sensitivePrefix = "!"
+
+// redactedValue - Replaces sensitive values in logs and errors
+const redactedValue = "(sensitive)"
+
+// sensitiveKeys - The top level keys of the response listed in 'sensitive_keys'
+func sensitiveKeys(d ResourceGetter) []string {
+	v, ok := d.GetOk("sensitive_keys")
+	if !ok {
+		return nil
+	}
+	list, ok := v.([]interface{})
+	if !ok {
+		log.Printf("sensitiveKeys() expected list in 'sensitive_keys' but got %#v", v)
+		return nil
+	}
+	keys := make([]string, 0, len(list))
+	for _, k := range list {
+		if s, ok := k.(string); ok {
+			keys = append(keys, s)
+		}
+	}
+	return keys
+}
+
+// isSensitive - The name of the field without the prefix, and whether it is sensitive
+func isSensitive(key string, keys []string) (string, bool) {
+	if strings.HasPrefix(key, sensitivePrefix) {
+		return strings.TrimPrefix(key, sensitivePrefix), true
+	}
+	return key, contains(keys, key)
+}
+
+// splitSensitive - Move the sensitive fields out of the response into a map of their own.
+// Fields named with the '!' prefix are stored without it.
+func splitSensitive(responseMap map[string]interface{}, keys []string) map[string]interface{} {
+	sensitive := map[string]interface{}{}
+	for k, v := range responseMap {
+		if name, ok := isSensitive(k, keys); ok {
+			sensitive[name] = v
+			delete(responseMap, k)
+		}
+	}
+	return sensitive
+}
+
+// redactResponse - The raw response of the script with the sensitive values replaced, for logs and errors
+func redactResponse(rawResponse []byte, keys []string) string {
+	var response map[string]interface{}
+	if json.Unmarshal(rawResponse, &response) != nil {
+		return string(rawResponse)
+	}
+	redacted := false
+	for k := range response {
+		if _, ok := isSensitive(k, keys); ok {
+			response[k] = redactedValue
+			redacted = true
+		}
+	}
+	if !redacted {
+		return string(rawResponse)
+	}
+	b, err := json.Marshal(response)
+	if err != nil {
+		return redactedValue
+	}
+	return string(b)
+}
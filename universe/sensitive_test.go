@@ -0,0 +1,76 @@
+package universe
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_splitSensitive(t *testing.T) {
+	response := map[string]interface{}{"id": "42", "!password": "p", "token": "t"}
+	sensitive := splitSensitive(response, []string{"token"})
+	if !reflect.DeepEqual(sensitive, map[string]interface{}{"password": "p", "token": "t"}) {
+		t.Errorf("unexpected sensitive fields %#v", sensitive)
+	}
+	if !reflect.DeepEqual(response, map[string]interface{}{"id": "42"}) {
+		t.Errorf("unexpected response %#v", response)
+	}
+}
+
+func Test_redactResponse(t *testing.T) {
+	redacted := redactResponse([]byte(`{"id": "42", "!password": "p", "token": "t"}`), []string{"token"})
+	if strings.Contains(redacted, `"p"`) || strings.Contains(redacted, `"t"`) || !strings.Contains(redacted, `"42"`) {
+		t.Errorf("expected the sensitive values to be redacted %s", redacted)
+	}
+	if redactResponse([]byte("true"), nil) != "true" {
+		t.Error("expected other responses unchanged")
+	}
+}
+
+func Test_callExecutorSensitive(t *testing.T) {
+	d := testResource("", `{"album": "white", "password_length": 4}`)
+	d.SensitiveKeys = types.ListValueMust(types.StringType, []attr.Value{types.StringValue("token")})
+	config := map[string]interface{}{
+		"id_key":   "id",
+		"executor": "python3",
+		"script":   "resource_universe_test.py",
+	}
+	if _, err := callExecutor("create", d, config); err != nil {
+		t.Fatal(err)
+	}
+	r := testJSON(t, d.Result)
+	if strings.Contains(r, `"password"`) || strings.Contains(r, "t0k3n") {
+		t.Errorf("expected no sensitive fields in the result %s", r)
+	}
+	if _, ok := d.Outputs.Elements()["token"]; ok {
+		t.Errorf("expected no sensitive fields in the outputs %s", d.Outputs)
+	}
+	sensitive := map[string]interface{}{}
+	_ = json.Unmarshal([]byte(testJSON(t, d.SensitiveResult)), &sensitive)
+	if sensitive["password"] != "xxxx" || sensitive["token"] != "t0k3n" {
+		t.Errorf("expected the sensitive fields in sensitive_result but got %#v", sensitive)
+	}
+}
+
+func Test_ProviderServerSensitiveSchema(t *testing.T) {
+	s := newTestServer(t)
+	resp, err := s.GetProviderSchema(context.Background(), &tfprotov5.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, attr := range resp.ResourceSchemas[DefaultProviderName].Block.Attributes {
+		if attr.Name == "sensitive_result" {
+			if !attr.Sensitive || !attr.Type.Is(tftypes.DynamicPseudoType) {
+				t.Errorf("expected a sensitive dynamic attribute but got %#v", attr)
+			}
+			return
+		}
+	}
+	t.Error("missing sensitive_result")
+}
@@ -0,0 +1,403 @@
+package universe
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"math/big"
+	"testing"
+)
+
+func testObjectConfig() tftypes.Value {
+	return tftypes.NewValue(tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"name": tftypes.String,
+		"size": tftypes.Number,
+		"tags": tftypes.Tuple{ElementTypes: []tftypes.Type{tftypes.String}},
+	}}, map[string]tftypes.Value{
+		"name": tftypes.NewValue(tftypes.String, "x"),
+		"size": tftypes.NewValue(tftypes.Number, big.NewFloat(20)),
+		"tags": tftypes.NewValue(tftypes.Tuple{ElementTypes: []tftypes.Type{tftypes.String}}, []tftypes.Value{
+			tftypes.NewValue(tftypes.String, "a"),
+		}),
+	})
+}
+
+func Test_ProviderServerSchema(t *testing.T) {
+	s := newTestServer(t)
+	resp, err := s.GetProviderSchema(context.Background(), &tfprotov5.GetProviderSchemaRequest{})
+	if err != nil {
+		t.FailNow()
+	}
+	resourceSchema, ok := resp.ResourceSchemas[DefaultProviderName]
+	if !ok {
+		t.FailNow()
+	}
+	found := 0
+	for _, attr := range resourceSchema.Block.Attributes {
+		if attr.Name == "config" || attr.Name == "result" {
+			found++
+			if !attr.Type.Is(tftypes.DynamicPseudoType) {
+				t.Errorf("expected %s to be dynamic", attr.Name)
+			}
+		}
+	}
+	if found != 2 {
+		t.Fail()
+	}
+}
+
+func Test_ProviderServerUpgradeV1(t *testing.T) {
+	s := newTestServer(t)
+	upgrade := func(rawState string) map[string]tftypes.Value {
+		resp, err := s.UpgradeResourceState(context.Background(), &tfprotov5.UpgradeResourceStateRequest{
+			TypeName: DefaultProviderName,
+			Version:  1,
+			RawState: &tfprotov5.RawState{JSON: []byte(rawState)},
+		})
+		if err != nil || len(resp.Diagnostics) != 0 {
+			t.Fatalf("upgrade failed %#v %#v", err, resp.Diagnostics)
+		}
+		value, err := resp.UpgradedState.Unmarshal(s.resourceType)
+		if err != nil {
+			t.FailNow()
+		}
+		attrs := map[string]tftypes.Value{}
+		_ = value.As(&attrs)
+		return attrs
+	}
+
+	// The response was written into config
+	attrs := upgrade(`{"id":"42","config":"{\"album\":\"white\",\"@created\":\"today\"}","id_key":"id"}`)
+	if !attrs["config"].Type().Is(tftypes.String) {
+		t.Errorf("expected config to stay a string but got %s", attrs["config"])
+	}
+	result := map[string]tftypes.Value{}
+	err := attrs["result"].As(&result)
+	if err != nil || !result["album"].Equal(tftypes.NewValue(tftypes.String, "white")) {
+		t.Errorf("expected the response in result but got %s", attrs["result"])
+	}
+	outputs := map[string]tftypes.Value{}
+	err = attrs["outputs"].As(&outputs)
+	if err != nil || !outputs["@created"].Equal(tftypes.NewValue(tftypes.String, "today")) {
+		t.Errorf("expected the response in outputs but got %s", attrs["outputs"])
+	}
+
+	// The response was written into result as a JSON string
+	attrs = upgrade(`{"id":"42","config":"album: white\n","result":"{\"album\":\"black\"}","outputs":{"album":"black"},"unordered_keys":["tags"]}`)
+	if !attrs["config"].Equal(tftypes.NewValue(tftypes.String, "album: white\n")) {
+		t.Errorf("expected config unchanged but got %s", attrs["config"])
+	}
+	err = attrs["result"].As(&result)
+	if err != nil || !result["album"].Equal(tftypes.NewValue(tftypes.String, "black")) {
+		t.Errorf("expected the result decoded but got %s", attrs["result"])
+	}
+	if attrs["unordered_keys"].IsNull() {
+		t.Errorf("expected unordered_keys to be kept")
+	}
+}
+
+// testServer - The provider as Terraform sees it, with the object type of the resource
+type testServer struct {
+	tfprotov5.ProviderServer
+	resourceType tftypes.Type
+}
+
+func newTestServer(t *testing.T) *testServer {
+	s := &testServer{ProviderServer: providerserver.NewProtocol5(Provider())()}
+	resp, err := s.GetProviderSchema(context.Background(), &tfprotov5.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.resourceType = resp.ResourceSchemas[DefaultProviderName].ValueType()
+	return s
+}
+
+// testServerValue - Make a value of the Terraform type of the resource, attributes not given are null
+func testServerValue(t *testing.T, s *testServer, attrs map[string]tftypes.Value) *tfprotov5.DynamicValue {
+	tfType := s.resourceType
+	values := map[string]tftypes.Value{}
+	for name, typ := range tfType.(tftypes.Object).AttributeTypes {
+		values[name] = tftypes.NewValue(typ, nil)
+	}
+	for name, v := range attrs {
+		values[name] = v
+	}
+	dv, err := tfprotov5.NewDynamicValue(tfType, tftypes.NewValue(tfType, values))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &dv
+}
+
+// testConfigureServer - Configure the provider to run the test script
+func testConfigureServer(t *testing.T, s *testServer) {
+	schemaResp, _ := s.GetProviderSchema(context.Background(), &tfprotov5.GetProviderSchemaRequest{})
+	providerType := schemaResp.Provider.ValueType()
+	values := map[string]tftypes.Value{}
+	for name, typ := range providerType.(tftypes.Object).AttributeTypes {
+		values[name] = tftypes.NewValue(typ, nil)
+	}
+	values["executor"] = tftypes.NewValue(tftypes.String, "python3")
+	values["script"] = tftypes.NewValue(tftypes.String, "resource_universe_test.py")
+	values["id_key"] = tftypes.NewValue(tftypes.String, "id")
+	config, err := tfprotov5.NewDynamicValue(providerType, tftypes.NewValue(providerType, values))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := s.ConfigureProvider(context.Background(), &tfprotov5.ConfigureProviderRequest{Config: &config})
+	if err != nil || len(resp.Diagnostics) != 0 {
+		t.Fatalf("configure failed %#v %#v", err, resp.Diagnostics)
+	}
+}
+
+// testCreate - Plan and apply a new resource
+func testCreate(t *testing.T, s *testServer, config *tfprotov5.DynamicValue) *tfprotov5.ApplyResourceChangeResponse {
+	priorNull, _ := tfprotov5.NewDynamicValue(s.resourceType, tftypes.NewValue(s.resourceType, nil))
+	plan, err := s.PlanResourceChange(context.Background(), &tfprotov5.PlanResourceChangeRequest{
+		TypeName:         DefaultProviderName,
+		PriorState:       &priorNull,
+		ProposedNewState: config,
+		Config:           config,
+	})
+	if err != nil || len(plan.Diagnostics) != 0 {
+		t.Fatalf("plan failed %#v %#v", err, plan.Diagnostics)
+	}
+	apply, err := s.ApplyResourceChange(context.Background(), &tfprotov5.ApplyResourceChangeRequest{
+		TypeName:       DefaultProviderName,
+		PriorState:     &priorNull,
+		PlannedState:   plan.PlannedState,
+		PlannedPrivate: plan.PlannedPrivate,
+		Config:         config,
+	})
+	if err != nil || len(apply.Diagnostics) != 0 {
+		t.Fatalf("apply failed %#v %#v", err, apply.Diagnostics)
+	}
+	return apply
+}
+
+func Test_ProviderServerCreate(t *testing.T) {
+	s := newTestServer(t)
+	testConfigureServer(t, s)
+	apply := testCreate(t, s, testServerValue(t, s, map[string]tftypes.Value{"config": testObjectConfig()}))
+	value, err := apply.NewState.Unmarshal(s.resourceType)
+	if err != nil {
+		t.FailNow()
+	}
+	attrs := map[string]tftypes.Value{}
+	_ = value.As(&attrs)
+	if !attrs["config"].Equal(testObjectConfig()) {
+		t.Errorf("expected config unchanged but got %s", attrs["config"])
+	}
+	if !attrs["id"].Equal(tftypes.NewValue(tftypes.String, "42")) {
+		t.Errorf("unexpected id %s", attrs["id"])
+	}
+	result := map[string]tftypes.Value{}
+	err = attrs["result"].As(&result)
+	if err != nil || !result["@created"].Equal(tftypes.NewValue(tftypes.String, "26/10/2020 18:55:51")) {
+		t.Errorf("expected the response in result but got %s", attrs["result"])
+	}
+}
+
+// testProposed - The new state Terraform proposes for the config: the config with the computed attributes of the prior state
+func testProposed(t *testing.T, s *testServer, prior *tfprotov5.DynamicValue, config *tfprotov5.DynamicValue) *tfprotov5.DynamicValue {
+	priorValue, _ := prior.Unmarshal(s.resourceType)
+	configValue, _ := config.Unmarshal(s.resourceType)
+	priorAttrs, attrs := map[string]tftypes.Value{}, map[string]tftypes.Value{}
+	_ = priorValue.As(&priorAttrs)
+	_ = configValue.As(&attrs)
+	for _, name := range computedAttributes {
+		attrs[name] = priorAttrs[name]
+	}
+	return testServerValue(t, s, attrs)
+}
+
+func Test_ProviderServerUpdate(t *testing.T) {
+	s := newTestServer(t)
+	testConfigureServer(t, s)
+	created := testCreate(t, s, testServerValue(t, s, map[string]tftypes.Value{"config": testObjectConfig()}))
+	update := func(config *tfprotov5.DynamicValue) (map[string]tftypes.Value, map[string]tftypes.Value) {
+		plan, err := s.PlanResourceChange(context.Background(), &tfprotov5.PlanResourceChangeRequest{
+			TypeName:         DefaultProviderName,
+			PriorState:       created.NewState,
+			ProposedNewState: testProposed(t, s, created.NewState, config),
+			Config:           config,
+			PriorPrivate:     created.Private,
+		})
+		if err != nil || len(plan.Diagnostics) != 0 || len(plan.RequiresReplace) != 0 {
+			t.Fatalf("plan failed %#v %#v %#v", err, plan.Diagnostics, plan.RequiresReplace)
+		}
+		apply, err := s.ApplyResourceChange(context.Background(), &tfprotov5.ApplyResourceChangeRequest{
+			TypeName:       DefaultProviderName,
+			PriorState:     created.NewState,
+			PlannedState:   plan.PlannedState,
+			PlannedPrivate: plan.PlannedPrivate,
+			Config:         config,
+		})
+		if err != nil || len(apply.Diagnostics) != 0 {
+			t.Fatalf("apply failed %#v %#v", err, apply.Diagnostics)
+		}
+		planned, applied := map[string]tftypes.Value{}, map[string]tftypes.Value{}
+		value, _ := plan.PlannedState.Unmarshal(s.resourceType)
+		_ = value.As(&planned)
+		value, _ = apply.NewState.Unmarshal(s.resourceType)
+		_ = value.As(&applied)
+		return planned, applied
+	}
+
+	// The same config written with jsonencode() is saved without running the script
+	jsonConfig := tftypes.NewValue(tftypes.String, `{"tags": ["a"], "size": 20, "name": "x"}`)
+	planned, applied := update(testServerValue(t, s, map[string]tftypes.Value{"config": jsonConfig}))
+	if !planned["id"].IsKnown() || !planned["result"].IsFullyKnown() || !planned["result"].Equal(applied["result"]) {
+		t.Errorf("expected the result to be kept but got %s then %s", planned["result"], applied["result"])
+	}
+	if !applied["config"].Equal(jsonConfig) {
+		t.Errorf("expected the config as written but got %s", applied["config"])
+	}
+
+	// A new config runs the script, which returns a new result
+	config := tftypes.NewValue(tftypes.Object{AttributeTypes: map[string]tftypes.Type{"name": tftypes.String}},
+		map[string]tftypes.Value{"name": tftypes.NewValue(tftypes.String, "y")})
+	planned, applied = update(testServerValue(t, s, map[string]tftypes.Value{"config": config}))
+	if !planned["id"].Equal(tftypes.NewValue(tftypes.String, "42")) || planned["result"].IsKnown() {
+		t.Errorf("expected the id kept and the result unknown until apply but got %s %s", planned["id"], planned["result"])
+	}
+	result := map[string]tftypes.Value{}
+	_ = applied["result"].As(&result)
+	if !applied["config"].Equal(config) || !result["name"].Equal(tftypes.NewValue(tftypes.String, "y")) {
+		t.Errorf("expected the new config in the result but got %s", applied["result"])
+	}
+}
+
+func Test_ProviderServerUpdateMutableID(t *testing.T) {
+	s := newTestServer(t)
+	testConfigureServer(t, s)
+	script := tftypes.NewValue(tftypes.String, "mutable_id_test.py")
+	created := testCreate(t, s, testServerValue(t, s, map[string]tftypes.Value{"config": testObjectConfig(), "script": script}))
+	config := testServerValue(t, s, map[string]tftypes.Value{"config": tftypes.NewValue(tftypes.String, `{"name": "y"}`), "script": script})
+	plan, err := s.PlanResourceChange(context.Background(), &tfprotov5.PlanResourceChangeRequest{
+		TypeName:         DefaultProviderName,
+		PriorState:       created.NewState,
+		ProposedNewState: testProposed(t, s, created.NewState, config),
+		Config:           config,
+		PriorPrivate:     created.Private,
+	})
+	if err != nil || len(plan.Diagnostics) != 0 || len(plan.RequiresReplace) != 0 {
+		t.Fatalf("plan failed %#v %#v %#v", err, plan.Diagnostics, plan.RequiresReplace)
+	}
+	planned := map[string]tftypes.Value{}
+	value, _ := plan.PlannedState.Unmarshal(s.resourceType)
+	_ = value.As(&planned)
+	if planned["id"].IsKnown() {
+		t.Errorf("expected the id to be unknown until apply with the mutable_id feature but got %s", planned["id"])
+	}
+	apply, err := s.ApplyResourceChange(context.Background(), &tfprotov5.ApplyResourceChangeRequest{
+		TypeName:       DefaultProviderName,
+		PriorState:     created.NewState,
+		PlannedState:   plan.PlannedState,
+		PlannedPrivate: plan.PlannedPrivate,
+		Config:         config,
+	})
+	if err != nil || len(apply.Diagnostics) != 0 {
+		t.Fatalf("apply failed %#v %#v", err, apply.Diagnostics)
+	}
+	applied := map[string]tftypes.Value{}
+	value, _ = apply.NewState.Unmarshal(s.resourceType)
+	_ = value.As(&applied)
+	if !applied["id"].Equal(tftypes.NewValue(tftypes.String, "43")) {
+		t.Errorf("expected the new id but got %s", applied["id"])
+	}
+}
+
+func Test_ProviderServerPlanEvent(t *testing.T) {
+	s := newTestServer(t)
+	testConfigureServer(t, s)
+	config := testServerValue(t, s, map[string]tftypes.Value{
+		"config":     testObjectConfig(),
+		"plan_event": tftypes.NewValue(tftypes.Bool, true),
+	})
+	priorNull, _ := tfprotov5.NewDynamicValue(s.resourceType, tftypes.NewValue(s.resourceType, nil))
+	plan, err := s.PlanResourceChange(context.Background(), &tfprotov5.PlanResourceChangeRequest{
+		TypeName:         DefaultProviderName,
+		PriorState:       &priorNull,
+		ProposedNewState: config,
+		Config:           config,
+	})
+	if err != nil || len(plan.Diagnostics) != 0 {
+		t.Fatalf("plan failed %#v %#v", err, plan.Diagnostics)
+	}
+	value, _ := plan.PlannedState.Unmarshal(s.resourceType)
+	planned, result := map[string]tftypes.Value{}, map[string]tftypes.Value{}
+	_ = value.As(&planned)
+	if err = planned["result"].As(&result); err != nil {
+		t.Fatalf("expected the predicted result but got %s", planned["result"])
+	}
+	if !result["id"].Equal(tftypes.NewValue(tftypes.String, "42")) || result["@created"].IsKnown() {
+		t.Errorf("unexpected predicted result %s", planned["result"])
+	}
+	apply := testCreate(t, s, config)
+	value, _ = apply.NewState.Unmarshal(s.resourceType)
+	applied := map[string]tftypes.Value{}
+	_ = value.As(&applied)
+	if !applied["result"].IsFullyKnown() {
+		t.Errorf("expected the result after apply but got %s", applied["result"])
+	}
+}
+
+func Test_ProviderServerImport(t *testing.T) {
+	s := newTestServer(t)
+	testConfigureServer(t, s)
+	resp, err := s.ImportResourceState(context.Background(), &tfprotov5.ImportResourceStateRequest{
+		TypeName: DefaultProviderName,
+		ID:       "album:id=42",
+	})
+	if err != nil || len(resp.Diagnostics) != 0 || len(resp.ImportedResources) != 1 {
+		t.Fatalf("import failed %#v %#v", err, resp.Diagnostics)
+	}
+	value, _ := resp.ImportedResources[0].State.Unmarshal(s.resourceType)
+	attrs, config := map[string]tftypes.Value{}, map[string]tftypes.Value{}
+	_ = value.As(&attrs)
+	if !attrs["id"].Equal(tftypes.NewValue(tftypes.String, "42")) {
+		t.Errorf("unexpected id %s", attrs["id"])
+	}
+	if err = attrs["config"].As(&config); err != nil || !config["album"].Equal(tftypes.NewValue(tftypes.String, "white")) {
+		t.Errorf("expected the imported config but got %s", attrs["config"])
+	}
+}
+
+func Test_ProviderServerPartialCreate(t *testing.T) {
+	s := newTestServer(t)
+	testConfigureServer(t, s)
+	failConfig := tftypes.NewValue(tftypes.Object{AttributeTypes: map[string]tftypes.Type{"fail": tftypes.String}},
+		map[string]tftypes.Value{"fail": tftypes.NewValue(tftypes.String, "error")})
+	config := testServerValue(t, s, map[string]tftypes.Value{"config": failConfig})
+	priorNull, _ := tfprotov5.NewDynamicValue(s.resourceType, tftypes.NewValue(s.resourceType, nil))
+	plan, err := s.PlanResourceChange(context.Background(), &tfprotov5.PlanResourceChangeRequest{
+		TypeName:         DefaultProviderName,
+		PriorState:       &priorNull,
+		ProposedNewState: config,
+		Config:           config,
+	})
+	if err != nil || len(plan.Diagnostics) != 0 {
+		t.Fatalf("plan failed %#v %#v", err, plan.Diagnostics)
+	}
+	apply, err := s.ApplyResourceChange(context.Background(), &tfprotov5.ApplyResourceChangeRequest{
+		TypeName:     DefaultProviderName,
+		PriorState:   &priorNull,
+		PlannedState: plan.PlannedState,
+		Config:       config,
+	})
+	if err != nil || len(apply.Diagnostics) == 0 {
+		t.Fatalf("expected the apply to fail %#v %#v", err, apply.Diagnostics)
+	}
+	// Terraform taints a new instance when the apply fails with a state
+	value, err := apply.NewState.Unmarshal(s.resourceType)
+	if err != nil || value.IsNull() {
+		t.Fatalf("expected the partly created instance in the state %#v", err)
+	}
+	attrs := map[string]tftypes.Value{}
+	_ = value.As(&attrs)
+	if !attrs["id"].Equal(tftypes.NewValue(tftypes.String, "44")) {
+		t.Errorf("unexpected id %s", attrs["id"])
+	}
+}
@@ -0,0 +1,109 @@
+package universe
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"log"
+)
+
+// upgradeRequest - The JSON passed on stdin to the script for the 'upgrade' event
+type upgradeRequest struct {
+	// Config - the config stored in the state, in the shape of FromVersion
+	Config interface{} `json:"config"`
+	// FromVersion - the config version stored in the state, 0 for state written before the script declared one
+	FromVersion int `json:"from_version"`
+	// ToVersion - the config version the script declares now
+	ToVersion int `json:"to_version"`
+}
+
+// configVersion - The version of the config shape, from 'config_version' in the provider block or the capabilities of the script
+func configVersion(effectiveDefaults map[string]interface{}, capabilities *scriptCapabilities) (int, bool) {
+	if version, ok := effectiveDefaults["config_version"].(int); ok && version > 0 {
+		return version, true
+	}
+	if capabilities != nil && capabilities.ConfigVersion > 0 {
+		return capabilities.ConfigVersion, true
+	}
+	return 0, false
+}
+
+// setConfigVersion - Record the config version in the state after the script has written the config.
+// Without a version one planned as unknown becomes null.
+func setConfigVersion(d *resourceModel, effectiveDefaults map[string]interface{}) {
+	version, ok := configVersion(effectiveDefaults, getCapabilities(effectiveDefaults))
+	if ok {
+		d.ConfigVersion = types.Int64Value(int64(version))
+	} else if d.ConfigVersion.IsUnknown() {
+		d.ConfigVersion = types.Int64Null()
+	}
+}
+
+// upgradeConfig - Run the 'upgrade' event when the config version in the state differs from the one declared,
+// replacing the stored config with the one returned by the script
+func upgradeConfig(d *resourceModel, providerConfig interface{}) error {
+	effectiveDefaults, id, err := extractEssentialFields("upgrade", d.settings(), providerConfig)
+	if err != nil {
+		return err
+	}
+	capabilities := getCapabilities(effectiveDefaults)
+	version, ok := configVersion(effectiveDefaults, capabilities)
+	if !ok {
+		return nil
+	}
+	stored := int(d.ConfigVersion.ValueInt64())
+	if stored == version {
+		return nil
+	}
+	if !capabilities.supportsEvent("upgrade") {
+		log.Printf("upgradeConfig() the script has no 'upgrade' event, recording version %d for '%s'", version, id)
+		d.ConfigVersion = types.Int64Value(int64(version))
+		return nil
+	}
+	configData, err := getConfigFromTF(d)
+	if err != nil {
+		return err
+	}
+	request := upgradeRequest{FromVersion: stored, ToVersion: version}
+	if request.Config, err = jsonSafeUnmarshal(configData, nil); err != nil {
+		return err
+	}
+	stdin, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	rawResponse, err := runScript("upgrade", id, effectiveDefaults, stdin)
+	if err != nil {
+		return fmt.Errorf("upgrading the config of '%s' from version %d to %d: %w", id, stored, version, err)
+	}
+	response, err := jsonSafeUnmarshal(rawResponse, nil)
+	if err != nil {
+		return fmt.Errorf("expecting JSON from the upgrade event, got '%s': %w", string(rawResponse), err)
+	}
+	config, ok := response.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("expecting an object from the upgrade event, got '%s'", string(rawResponse))
+	}
+	log.Printf("upgradeConfig() upgraded the config of '%s' from version %d to %d: %s", id, stored, version, string(rawResponse))
+	if err = setConfig(d, config); err != nil {
+		return err
+	}
+	d.ConfigVersion = types.Int64Value(int64(version))
+	return nil
+}
+
+// planConfigVersion - Plan the config version an update will record, unknown until the executor and script are known
+func planConfigVersion(d *resourceModel, providerConfig interface{}) {
+	if d.Executor.IsUnknown() || d.Script.IsUnknown() {
+		d.ConfigVersion = types.Int64Unknown()
+		return
+	}
+	effectiveDefaults, _, err := extractEssentialFields("capabilities", d.settings(), providerConfig)
+	if err != nil {
+		d.ConfigVersion = types.Int64Unknown()
+		return
+	}
+	if version, ok := configVersion(effectiveDefaults, getCapabilities(effectiveDefaults)); ok {
+		d.ConfigVersion = types.Int64Value(int64(version))
+	} // Otherwise the update keeps the version in the state
+}
@@ -0,0 +1,60 @@
+package universe
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"testing"
+)
+
+func Test_configVersion(t *testing.T) {
+	if _, ok := configVersion(map[string]interface{}{}, nil); ok {
+		t.Error("expected no version")
+	}
+	if v, ok := configVersion(map[string]interface{}{}, &scriptCapabilities{ConfigVersion: 3}); !ok || v != 3 {
+		t.Errorf("expected the version of the script but got %d", v)
+	}
+	if v, ok := configVersion(map[string]interface{}{"config_version": 2}, &scriptCapabilities{ConfigVersion: 3}); !ok || v != 2 {
+		t.Errorf("expected the version of the provider but got %d", v)
+	}
+}
+
+func Test_upgradeConfig(t *testing.T) {
+	config := map[string]interface{}{
+		"id_key":         "id",
+		"executor":       "python3",
+		"script":         "resource_universe_test.py",
+		"config_version": 2,
+	}
+	d := testResource("42", `{"title": "white"}`)
+	if err := upgradeConfig(d, config); err != nil {
+		t.Fatal(err)
+	}
+	n1, _ := normalizeJSONString(d.Config.UnderlyingValue().(types.String).ValueString())
+	if n1 != `{"album":"white"}` {
+		t.Errorf("expected the upgraded config but got %s", n1)
+	}
+	if d.ConfigVersion.ValueInt64() != 2 {
+		t.Errorf("expected the version to be recorded but got %s", d.ConfigVersion)
+	}
+
+	// The same version is left alone
+	d.Config = types.DynamicValue(types.StringValue(`{"title": "black"}`))
+	if err := upgradeConfig(d, config); err != nil || !d.Config.Equal(types.DynamicValue(types.StringValue(`{"title": "black"}`))) {
+		t.Errorf("expected no upgrade %#v %s", err, d.Config)
+	}
+}
+
+func Test_callExecutorCreateConfigVersion(t *testing.T) {
+	d := testResource("", `{"album": "white"}`)
+	config := map[string]interface{}{
+		"id_key":         "id",
+		"executor":       "python3",
+		"script":         "resource_universe_test.py",
+		"config_version": 2,
+	}
+	if _, err := callExecutor("create", d, config); err != nil {
+		t.Fatal(err)
+	}
+	if d.ConfigVersion.ValueInt64() != 2 {
+		t.Errorf("expected the version to be recorded but got %s", d.ConfigVersion)
+	}
+}